@@ -0,0 +1,124 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+import (
+	"errors"
+	"image"
+)
+
+// An Atlas is a set of large images (pages) where small images are packed.
+//
+// Images created by an Atlas share the textures of the pages,
+// which saves memory and avoids switching textures when they are drawn one after another.
+// Such images can be used only as sources of DrawImage, not as render targets.
+type Atlas struct {
+	pageSize int
+	filter   Filter
+	pages    []*atlasPage
+}
+
+type atlasPage struct {
+	image     *Image
+	x         int
+	y         int
+	rowHeight int
+}
+
+// alloc allocates a region with shelf packing.
+func (p *atlasPage) alloc(width, height, pageSize int) (image.Point, bool) {
+	// Leave 1 pixel between images not to bleed with FilterLinear.
+	width++
+	height++
+	// The page is updated only when the region is allocated,
+	// or a failed allocation would abandon the current row.
+	x, y, rowHeight := p.x, p.y, p.rowHeight
+	if pageSize < x+width {
+		x = 0
+		y += rowHeight
+		rowHeight = 0
+	}
+	if pageSize < x+width || pageSize < y+height {
+		return image.Point{}, false
+	}
+	if rowHeight < height {
+		rowHeight = height
+	}
+	p.x, p.y, p.rowHeight = x+width, y, rowHeight
+	return image.Point{x, y}, true
+}
+
+// NewAtlas returns a new atlas.
+// The width and the height of each page is pageSize. Pages are created when needed.
+func NewAtlas(pageSize int, filter Filter) *Atlas {
+	return &Atlas{
+		pageSize: pageSize,
+		filter:   filter,
+	}
+}
+
+func (a *Atlas) alloc(width, height int) (*atlasPage, image.Point, error) {
+	for _, p := range a.pages {
+		if pt, ok := p.alloc(width, height, a.pageSize); ok {
+			return p, pt, nil
+		}
+	}
+	img, err := NewImage(a.pageSize, a.pageSize, a.filter)
+	if err != nil {
+		return nil, image.Point{}, err
+	}
+	p := &atlasPage{image: img}
+	a.pages = append(a.pages, p)
+	pt, ok := p.alloc(width, height, a.pageSize)
+	if !ok {
+		return nil, image.Point{}, errors.New("ebiten: the image is too big for the atlas")
+	}
+	return p, pt, nil
+}
+
+// NewImageFromImage creates a new image with the given image (img) in the atlas.
+//
+// The returned image can be used only as a source of DrawImage.
+func (a *Atlas) NewImageFromImage(img image.Image) (*Image, error) {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if a.pageSize < w+1 || a.pageSize < h+1 {
+		return nil, errors.New("ebiten: the image is too big for the atlas")
+	}
+	page, pt, err := a.alloc(w, h)
+	if err != nil {
+		return nil, err
+	}
+	src, err := NewImageFromImage(img, a.filter)
+	if err != nil {
+		return nil, err
+	}
+	op := &DrawImageOptions{
+		ImageParts: imageParts{{
+			Dst: image.Rectangle{pt, pt.Add(image.Pt(w, h))},
+			Src: image.Rect(0, 0, w, h),
+		}},
+	}
+	if err := page.image.DrawImage(src, op); err != nil {
+		return nil, err
+	}
+	if err := src.Dispose(); err != nil {
+		return nil, err
+	}
+	return &Image{
+		texture: page.image.texture,
+		parent:  page.image,
+		region:  image.Rectangle{pt, pt.Add(image.Pt(w, h))},
+	}, nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten_test
+
+import (
+	. "github.com/hajimehoshi/ebiten"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestAtlas(t *testing.T) {
+	img0, src0, err := openImage("testdata/ebiten.png")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	w, h := img0.Size()
+
+	a := NewAtlas(256, FilterNearest)
+	src1 := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	src1.Set(0, 0, color.RGBA{0xff, 0, 0, 0xff})
+	src1.Set(1, 0, color.RGBA{0, 0xff, 0, 0xff})
+	img1, err := a.NewImageFromImage(src1)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	img2, err := a.NewImageFromImage(src0)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if got := img2.Bounds().Size(); got != image.Pt(w, h) {
+		t.Errorf("img2 size: got %v; want %v", got, image.Pt(w, h))
+	}
+
+	dst, err := NewImage(w, h, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if err := dst.DrawImage(img2, nil); err != nil {
+		t.Fatal(err)
+		return
+	}
+	op := &DrawImageOptions{}
+	op.GeoM.Translate(1, 1)
+	if err := dst.DrawImage(img1, op); err != nil {
+		t.Fatal(err)
+		return
+	}
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			want := img0.At(i, j)
+			switch {
+			case i == 1 && j == 1:
+				want = color.RGBA{0xff, 0, 0, 0xff}
+			case i == 2 && j == 1:
+				want = color.RGBA{0, 0xff, 0, 0xff}
+			}
+			if got := dst.At(i, j); got != want {
+				t.Errorf("dst.At(%d, %d): got %v; want %v", i, j, got, want)
+			}
+		}
+	}
+	if got, want := img1.At(1, 0), (color.RGBA{0, 0xff, 0, 0xff}); got != want {
+		t.Errorf("img1.At(1, 0): got %v; want %v", got, want)
+	}
+	if err := img1.Fill(color.White); err == nil {
+		t.Errorf("img1.Fill(color.White) doesn't return error; an error should be returned")
+	}
+}
+
+func TestAtlasKeepsRowAfterFailure(t *testing.T) {
+	a := NewAtlas(16, FilterNearest)
+	img0, err := a.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 8, 4)))
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	// This image doesn't fit the first page and goes to a new page.
+	img1, err := a.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 8, 15)))
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	// The failed allocation must not abandon the current row of the first page.
+	img2, err := a.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 4, 4)))
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	testCases := []struct {
+		img  *Image
+		want image.Rectangle
+	}{
+		{img0, image.Rect(0, 0, 8, 4)},
+		{img1, image.Rect(0, 0, 8, 15)},
+		{img2, image.Rect(9, 0, 13, 4)},
+	}
+	for i, c := range testCases {
+		if got := RegionInAtlas(c.img); got != c.want {
+			t.Errorf("img%d: region: got %v; want %v", i, got, c.want)
+		}
+	}
+}
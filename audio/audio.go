@@ -0,0 +1,311 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audio provides audio players.
+//
+// The sample format is 16-bit signed little endian and 2 channels (stereo), interleaved.
+// Sounds are mixed by software at every frame of ebiten.Run
+// and the result is sent to the output device.
+package audio
+
+import (
+	"errors"
+	"github.com/hajimehoshi/ebiten/internal/hooks"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	channelNum     = 2
+	bytesPerSample = 2 * channelNum
+
+	// framesPerSecond is the assumed number of calls of Update per second.
+	framesPerSecond = 60
+)
+
+// A driver represents an output device.
+// buf is a PCM stream in the sample format of this package.
+type driver interface {
+	Write(buf []byte) error
+	Close() error
+}
+
+// A Context represents the audio context. The sample rate is fixed.
+type Context struct {
+	sampleRate int
+	driver     driver
+	players    map[*Player]struct{}
+	frames     int
+	closed     bool
+	m          sync.Mutex
+
+	// driverM is locked while the driver is used not to close the driver while writing.
+	driverM sync.Mutex
+
+	// hook calls Update at every frame. hook is nil when the context is created by newContext directly (e.g. in tests).
+	hook *hooks.Hook
+}
+
+// NewContext creates a new audio context with the given sample rate (e.g. 44100).
+//
+// The context is updated at every frame of ebiten.Run automatically.
+func NewContext(sampleRate int) (*Context, error) {
+	if sampleRate <= 0 {
+		return nil, errors.New("audio: sample rate must be positive")
+	}
+	d, err := newDriver(sampleRate)
+	if err != nil {
+		return nil, err
+	}
+	c := newContext(sampleRate, d)
+	c.hook = hooks.AppendHookOnUpdate(c.Update)
+	return c, nil
+}
+
+func newContext(sampleRate int, d driver) *Context {
+	return &Context{
+		sampleRate: sampleRate,
+		driver:     d,
+		players:    map[*Player]struct{}{},
+	}
+}
+
+// SampleRate returns the sample rate of the context.
+func (c *Context) SampleRate() int {
+	return c.sampleRate
+}
+
+// Update mixes the sounds for one frame and sends them to the output device.
+//
+// This is called from ebiten.Run automatically.
+// You don't have to call this unless you run a game loop by yourself.
+func (c *Context) Update() error {
+	c.m.Lock()
+	if c.closed {
+		c.m.Unlock()
+		return nil
+	}
+	// Accumulate the remainder not to drift when the sample rate is not a multiple of the frame rate.
+	c.frames += c.sampleRate
+	n := c.frames / framesPerSecond
+	c.frames %= framesPerSecond
+	c.m.Unlock()
+
+	buf, err := c.mix(n)
+	if err != nil {
+		return err
+	}
+	c.driverM.Lock()
+	defer c.driverM.Unlock()
+	if c.isClosed() {
+		return nil
+	}
+	return c.driver.Write(buf)
+}
+
+func (c *Context) isClosed() bool {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.closed
+}
+
+// Close stops updating the context and releases the output device.
+// The context is no longer updated by ebiten.Run.
+// After Close, Update does nothing.
+func (c *Context) Close() error {
+	c.m.Lock()
+	if c.closed {
+		c.m.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.m.Unlock()
+	// The closed context is no longer referred from the game loop and can be garbage-collected.
+	if c.hook != nil {
+		hooks.RemoveHookOnUpdate(c.hook)
+	}
+	c.driverM.Lock()
+	defer c.driverM.Unlock()
+	return c.driver.Close()
+}
+
+// mix reads n samples from each playing player and returns the mixed PCM stream.
+// The streams are read without locking the context since reading might block.
+func (c *Context) mix(n int) ([]byte, error) {
+	c.m.Lock()
+	players := []*Player{}
+	for p := range c.players {
+		if p.playing {
+			players = append(players, p)
+		}
+	}
+	c.m.Unlock()
+
+	sum := make([]float64, n*channelNum)
+	src := make([]byte, n*bytesPerSample)
+	for _, p := range players {
+		l, volume, err := p.read(src)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < l/2; i++ {
+			v := int16(src[2*i]) | int16(src[2*i+1])<<8
+			sum[i] += float64(v) * volume
+		}
+	}
+	buf := make([]byte, n*bytesPerSample)
+	for i, s := range sum {
+		v := int16(math.Max(math.Min(s, math.MaxInt16), math.MinInt16))
+		buf[2*i] = uint8(v)
+		buf[2*i+1] = uint8(v >> 8)
+	}
+	return buf, nil
+}
+
+// A Player represents a channel which plays a PCM stream.
+type Player struct {
+	context *Context
+	src     io.Reader
+	pos     int64
+	playing bool
+	volume  float64
+
+	// srcM is locked while src is used. This must be locked before the context's lock.
+	srcM sync.Mutex
+}
+
+// read reads samples from the stream and returns the byte size and the volume.
+// The player stops at the end of the stream.
+func (p *Player) read(buf []byte) (int, float64, error) {
+	p.srcM.Lock()
+	defer p.srcM.Unlock()
+	l, err := io.ReadFull(p.src, buf)
+	eof := err == io.EOF || err == io.ErrUnexpectedEOF
+	if err != nil && !eof {
+		return 0, 0, err
+	}
+	l -= l % bytesPerSample
+
+	p.context.m.Lock()
+	defer p.context.m.Unlock()
+	p.pos += int64(l)
+	if eof {
+		p.playing = false
+	}
+	return l, p.volume, nil
+}
+
+// NewPlayer creates a new player with the given PCM stream.
+//
+// src must be in the sample format of this package at the context's sample rate.
+// A new player is paused and its volume is 1.
+func (c *Context) NewPlayer(src io.Reader) (*Player, error) {
+	p := &Player{
+		context: c,
+		src:     src,
+		volume:  1,
+	}
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.players[p] = struct{}{}
+	return p, nil
+}
+
+// Play starts or resumes playing.
+func (p *Player) Play() error {
+	p.context.m.Lock()
+	defer p.context.m.Unlock()
+	p.playing = true
+	return nil
+}
+
+// Pause pauses playing.
+func (p *Player) Pause() error {
+	p.context.m.Lock()
+	defer p.context.m.Unlock()
+	p.playing = false
+	return nil
+}
+
+// IsPlaying returns a boolean indicating whether the player is playing.
+// A player stops playing at the end of the stream.
+func (p *Player) IsPlaying() bool {
+	p.context.m.Lock()
+	defer p.context.m.Unlock()
+	return p.playing
+}
+
+// Rewind seeks to the beginning of the stream.
+func (p *Player) Rewind() error {
+	return p.Seek(0)
+}
+
+// Seek seeks the position with the given offset from the beginning.
+//
+// The stream must implement io.Seeker.
+func (p *Player) Seek(offset time.Duration) error {
+	s, ok := p.src.(io.Seeker)
+	if !ok {
+		return errors.New("audio: the stream is not seekable")
+	}
+	p.srcM.Lock()
+	defer p.srcM.Unlock()
+	o := int64(offset) * int64(p.context.sampleRate) / int64(time.Second) * bytesPerSample
+	pos, err := s.Seek(o, 0)
+	if err != nil {
+		return err
+	}
+	p.context.m.Lock()
+	defer p.context.m.Unlock()
+	p.pos = pos
+	return nil
+}
+
+// Current returns the current position.
+func (p *Player) Current() time.Duration {
+	p.context.m.Lock()
+	defer p.context.m.Unlock()
+	return time.Duration(p.pos/bytesPerSample) * time.Second / time.Duration(p.context.sampleRate)
+}
+
+// Volume returns the current volume of the player [0-1].
+func (p *Player) Volume() float64 {
+	p.context.m.Lock()
+	defer p.context.m.Unlock()
+	return p.volume
+}
+
+// SetVolume sets the volume of the player [0-1].
+func (p *Player) SetVolume(volume float64) {
+	p.context.m.Lock()
+	defer p.context.m.Unlock()
+	p.volume = math.Max(math.Min(volume, 1), 0)
+}
+
+// Close removes the player from the context.
+// If the stream implements io.Closer, the stream is also closed.
+func (p *Player) Close() error {
+	p.context.m.Lock()
+	delete(p.context.players, p)
+	p.playing = false
+	p.context.m.Unlock()
+	p.srcM.Lock()
+	defer p.srcM.Unlock()
+	if c, ok := p.src.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func pcm(samples ...int16) []byte {
+	b := make([]byte, 2*len(samples))
+	for i, s := range samples {
+		b[2*i] = uint8(s)
+		b[2*i+1] = uint8(s >> 8)
+	}
+	return b
+}
+
+func TestMix(t *testing.T) {
+	c := newContext(60, &nullDriver{})
+	p0, _ := c.NewPlayer(bytes.NewReader(pcm(100, -100, 30000, -30000)))
+	p1, _ := c.NewPlayer(bytes.NewReader(pcm(50, 50, 10000, -10000, 1, 1)))
+	p0.Play()
+	p1.Play()
+	p1.SetVolume(0.5)
+
+	got, err := c.mix(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := pcm(125, -75, 32767, -32768, 0, 0)
+	if !bytes.Equal(got, want) {
+		t.Errorf("c.mix(3): got %v; want %v", got, want)
+	}
+	if p0.IsPlaying() {
+		t.Errorf("p0.IsPlaying(): got true; want false")
+	}
+	if !p1.IsPlaying() {
+		t.Errorf("p1.IsPlaying(): got false; want true")
+	}
+	if _, err := c.mix(1); err != nil {
+		t.Fatal(err)
+	}
+	if p1.IsPlaying() {
+		t.Errorf("p1.IsPlaying() at the end of the stream: got true; want false")
+	}
+}
+
+func TestPauseAndSeek(t *testing.T) {
+	c := newContext(60, &nullDriver{})
+	p, _ := c.NewPlayer(bytes.NewReader(pcm(1, 1, 2, 2, 3, 3, 4, 4)))
+	if got, _ := c.mix(1); !bytes.Equal(got, pcm(0, 0)) {
+		t.Errorf("paused: got %v; want %v", got, pcm(0, 0))
+	}
+	p.Play()
+	if got, _ := c.mix(1); !bytes.Equal(got, pcm(1, 1)) {
+		t.Errorf("playing: got %v; want %v", got, pcm(1, 1))
+	}
+	if got, want := p.Current(), time.Second/60; got != want {
+		t.Errorf("p.Current(): got %v; want %v", got, want)
+	}
+	if err := p.Seek(3 * time.Second / 60); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := c.mix(1); !bytes.Equal(got, pcm(4, 4)) {
+		t.Errorf("after seeking: got %v; want %v", got, pcm(4, 4))
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	d := &nullDriver{}
+	c := newContext(44100, d)
+	for i := 0; i < 60; i++ {
+		if err := c.Update(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := d.written, 44100*bytesPerSample; got != want {
+		t.Errorf("written bytes in a second: got %d; want %d", got, want)
+	}
+}
+
+func TestClose(t *testing.T) {
+	d := &nullDriver{}
+	c := newContext(44100, d)
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !d.closed {
+		t.Errorf("the driver must be closed")
+	}
+	if err := c.Update(); err != nil {
+		t.Fatal(err)
+	}
+	if d.written != 0 {
+		t.Errorf("written bytes after Close: got %d; want 0", d.written)
+	}
+}
+
+type blockingReader struct {
+	ch chan struct{}
+}
+
+func (b *blockingReader) Read(p []byte) (int, error) {
+	<-b.ch
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+func TestMixWithBlockingStream(t *testing.T) {
+	c := newContext(60, &nullDriver{})
+	r := &blockingReader{ch: make(chan struct{})}
+	p, _ := c.NewPlayer(r)
+	p.Play()
+	done := make(chan struct{})
+	go func() {
+		c.mix(1)
+		close(done)
+	}()
+	// The context must not be locked while the stream is blocked.
+	p.SetVolume(0.5)
+	if !p.IsPlaying() {
+		t.Errorf("p.IsPlaying(): got false; want true")
+	}
+	close(r.ch)
+	<-done
+}
@@ -0,0 +1,77 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build js
+
+package audio
+
+import (
+	"errors"
+	"github.com/gopherjs/gopherjs/js"
+)
+
+type webAudioDriver struct {
+	sampleRate int
+	context    js.Object
+	next       float64
+}
+
+func newDriver(sampleRate int) (driver, error) {
+	class := js.Global.Get("AudioContext")
+	if class == js.Undefined {
+		class = js.Global.Get("webkitAudioContext")
+	}
+	if class == js.Undefined {
+		return nil, errors.New("audio: Web Audio API is not available")
+	}
+	return &webAudioDriver{
+		sampleRate: sampleRate,
+		context:    class.New(),
+	}, nil
+}
+
+func (d *webAudioDriver) Write(buf []byte) error {
+	const max = 1 << 15
+	n := len(buf) / bytesPerSample
+	if n == 0 {
+		return nil
+	}
+	b := d.context.Call("createBuffer", channelNum, n, d.sampleRate)
+	l := b.Call("getChannelData", 0)
+	r := b.Call("getChannelData", 1)
+	for i := 0; i < n; i++ {
+		lv := int16(buf[4*i]) | int16(buf[4*i+1])<<8
+		rv := int16(buf[4*i+2]) | int16(buf[4*i+3])<<8
+		l.SetIndex(i, float64(lv)/max)
+		r.SetIndex(i, float64(rv)/max)
+	}
+	s := d.context.Call("createBufferSource")
+	s.Set("buffer", b)
+	s.Call("connect", d.context.Get("destination"))
+	// Schedule the buffers in order without gaps.
+	now := d.context.Get("currentTime").Float()
+	if d.next < now {
+		d.next = now
+	}
+	s.Call("start", d.next)
+	d.next += float64(n) / float64(d.sampleRate)
+	return nil
+}
+
+func (d *webAudioDriver) Close() error {
+	if d.context.Get("close") != js.Undefined {
+		d.context.Call("close")
+	}
+	return nil
+}
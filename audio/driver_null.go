@@ -0,0 +1,32 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+// nullDriver is an output device which discards everything.
+// This is used in the headless mode and for testing.
+type nullDriver struct {
+	written int
+	closed  bool
+}
+
+func (d *nullDriver) Write(buf []byte) error {
+	d.written += len(buf)
+	return nil
+}
+
+func (d *nullDriver) Close() error {
+	d.closed = true
+	return nil
+}
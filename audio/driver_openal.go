@@ -0,0 +1,77 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !js,!headless
+
+package audio
+
+import (
+	"errors"
+	"golang.org/x/mobile/exp/audio/al"
+)
+
+// queuedBufferNum is the number of the OpenAL buffers.
+// Each buffer holds the samples for one frame.
+const queuedBufferNum = 8
+
+type openALDriver struct {
+	sampleRate int
+	source     al.Source
+	buffers    []al.Buffer
+}
+
+func newDriver(sampleRate int) (driver, error) {
+	if err := al.OpenDevice(); err != nil {
+		return nil, err
+	}
+	sources := al.GenSources(1)
+	if len(sources) == 0 {
+		return nil, errors.New("audio: alGenSources failed")
+	}
+	return &openALDriver{
+		sampleRate: sampleRate,
+		source:     sources[0],
+		buffers:    al.GenBuffers(queuedBufferNum),
+	}, nil
+}
+
+func (d *openALDriver) Write(buf []byte) error {
+	if n := d.source.BuffersProcessed(); 0 < n {
+		bufs := make([]al.Buffer, n)
+		d.source.UnqueueBuffers(bufs...)
+		d.buffers = append(d.buffers, bufs...)
+	}
+	if len(d.buffers) == 0 {
+		// The device is behind the game loop. Drop the samples.
+		return nil
+	}
+	b := d.buffers[0]
+	d.buffers = d.buffers[1:]
+	b.BufferData(al.FormatStereo16, buf, int32(d.sampleRate))
+	d.source.QueueBuffers(b)
+	if d.source.State() != al.Playing {
+		al.PlaySources(d.source)
+	}
+	if err := al.Error(); err != 0 {
+		return errors.New("audio: OpenAL error")
+	}
+	return nil
+}
+
+func (d *openALDriver) Close() error {
+	al.StopSources(d.source)
+	al.DeleteSources(d.source)
+	al.CloseDevice()
+	return nil
+}
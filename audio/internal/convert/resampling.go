@@ -0,0 +1,141 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package convert provides conversions of PCM streams.
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+const bytesPerFrame = 4
+
+// resamplingBlockFrames is the number of frames read from the source at once.
+const resamplingBlockFrames = 4096
+
+// A Resampling is a stream that converts the sample rate of the source
+// by linear interpolation. Both streams are 16-bit signed little endian stereo.
+type Resampling struct {
+	src        io.ReadSeeker
+	size       int64
+	from       int
+	to         int
+	pos        int64
+	block      []int16
+	blockFrame int64
+}
+
+// NewResampling returns a new Resampling.
+// size is the byte size of src. from and to are the sample rates of src and the result.
+// NewResampling returns an error when the sample rates are not positive.
+func NewResampling(src io.ReadSeeker, size int64, from, to int) (*Resampling, error) {
+	if from <= 0 || to <= 0 {
+		return nil, errors.New(fmt.Sprintf("convert: sample rates must be positive but %d and %d", from, to))
+	}
+	return &Resampling{
+		src:        src,
+		size:       size,
+		from:       from,
+		to:         to,
+		blockFrame: -1,
+	}, nil
+}
+
+// Size returns the byte size of the resampled stream.
+func (r *Resampling) Size() int64 {
+	frames := r.size / bytesPerFrame * int64(r.to) / int64(r.from)
+	return frames * bytesPerFrame
+}
+
+func (r *Resampling) srcFrames() int64 {
+	return r.size / bytesPerFrame
+}
+
+func (r *Resampling) frame(i int64) (left, right int16, err error) {
+	if i < 0 || r.srcFrames() <= i {
+		return 0, 0, nil
+	}
+	b := i / resamplingBlockFrames * resamplingBlockFrames
+	if r.blockFrame != b {
+		if _, err := r.src.Seek(b*bytesPerFrame, 0); err != nil {
+			return 0, 0, err
+		}
+		buf := make([]byte, resamplingBlockFrames*bytesPerFrame)
+		n, err := io.ReadFull(r.src, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return 0, 0, err
+		}
+		r.block = make([]int16, n/2)
+		for j := range r.block {
+			r.block[j] = int16(buf[2*j]) | int16(buf[2*j+1])<<8
+		}
+		r.blockFrame = b
+	}
+	j := int(i - b)
+	if len(r.block) <= 2*j+1 {
+		return 0, 0, nil
+	}
+	return r.block[2*j], r.block[2*j+1], nil
+}
+
+func (r *Resampling) Read(b []byte) (int, error) {
+	if r.Size() <= r.pos {
+		return 0, io.EOF
+	}
+	n := len(b) / bytesPerFrame * bytesPerFrame
+	if rest := r.Size() - r.pos; rest < int64(n) {
+		n = int(rest)
+	}
+	for i := 0; i < n/bytesPerFrame; i++ {
+		frame := r.pos/bytesPerFrame + int64(i)
+		p := float64(frame) * float64(r.from) / float64(r.to)
+		p0 := math.Floor(p)
+		t := p - p0
+		l0, r0, err := r.frame(int64(p0))
+		if err != nil {
+			return 0, err
+		}
+		l1, r1, err := r.frame(int64(p0) + 1)
+		if err != nil {
+			return 0, err
+		}
+		l := int16(float64(l0)*(1-t) + float64(l1)*t)
+		rr := int16(float64(r0)*(1-t) + float64(r1)*t)
+		b[4*i] = uint8(l)
+		b[4*i+1] = uint8(l >> 8)
+		b[4*i+2] = uint8(rr)
+		b[4*i+3] = uint8(rr >> 8)
+	}
+	r.pos += int64(n)
+	return n, nil
+}
+
+func (r *Resampling) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case 0:
+		r.pos = offset
+	case 1:
+		r.pos += offset
+	case 2:
+		r.pos = r.Size() + offset
+	}
+	r.pos = r.pos / bytesPerFrame * bytesPerFrame
+	if r.pos < 0 {
+		r.pos = 0
+	}
+	return r.pos, nil
+}
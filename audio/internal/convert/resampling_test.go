@@ -0,0 +1,96 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+// frames returns a stereo PCM stream whose left and right samples are both vs[i] at the frame i.
+func frames(vs ...int16) []byte {
+	b := make([]byte, 4*len(vs))
+	for i, v := range vs {
+		b[4*i] = uint8(v)
+		b[4*i+1] = uint8(v >> 8)
+		b[4*i+2] = uint8(v)
+		b[4*i+3] = uint8(v >> 8)
+	}
+	return b
+}
+
+func TestResampling(t *testing.T) {
+	testCases := []struct {
+		src  []int16
+		from int
+		to   int
+		want []int16
+	}{
+		{[]int16{0, 100, -100}, 1, 1, []int16{0, 100, -100}},
+		{[]int16{0, 100}, 2, 4, []int16{0, 50, 100, 50}},
+		{[]int16{0, 10, 20, 30}, 4, 2, []int16{0, 20}},
+		{[]int16{0, 30, 60}, 3, 2, []int16{0, 45}},
+	}
+	for _, c := range testCases {
+		src := frames(c.src...)
+		r, err := NewResampling(bytes.NewReader(src), int64(len(src)), c.from, c.to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := r.Size(), int64(4*len(c.want)); got != want {
+			t.Errorf("%v (%d -> %d): Size(): got %d; want %d", c.src, c.from, c.to, got, want)
+		}
+		got, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := frames(c.want...); !bytes.Equal(got, want) {
+			t.Errorf("%v (%d -> %d): got %v; want %v", c.src, c.from, c.to, got, want)
+		}
+	}
+}
+
+func TestResamplingSeek(t *testing.T) {
+	src := frames(0, 100, 200, 300)
+	r, err := NewResampling(bytes.NewReader(src), int64(len(src)), 2, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The offset is rounded down to the frame.
+	pos, err := r.Seek(4*3+1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos != 4*3 {
+		t.Errorf("Seek: got %d; want %d", pos, 4*3)
+	}
+	got := make([]byte, 8)
+	if _, err := r.Read(got); err != nil {
+		t.Fatal(err)
+	}
+	if want := frames(150, 200); !bytes.Equal(got, want) {
+		t.Errorf("after seeking: got %v; want %v", got, want)
+	}
+}
+
+func TestResamplingInvalidSampleRate(t *testing.T) {
+	src := frames(0, 100)
+	for _, c := range [][2]int{{0, 44100}, {44100, 0}, {-1, 44100}} {
+		if _, err := NewResampling(bytes.NewReader(src), int64(len(src)), c[0], c[1]); err == nil {
+			t.Errorf("NewResampling with %d -> %d must return an error", c[0], c[1])
+		}
+	}
+}
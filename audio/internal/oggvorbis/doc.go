@@ -0,0 +1,8 @@
+/*
+Package oggvorbis decodes audio from ogg/vorbis files.
+
+This package is a copy of github.com/jfreymuth/oggvorbis v1.0.5 (MIT License, see LICENSE)
+so that the audio packages don't depend on packages outside this repository.
+The io.Seek constants are replaced for older Go versions.
+*/
+package oggvorbis
@@ -0,0 +1,83 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"io"
+)
+
+// A ReadSeekCloser is the interface for the sources of decoders.
+type ReadSeekCloser interface {
+	io.ReadSeeker
+	io.Closer
+}
+
+// An InfiniteLoop is a stream which repeats the source stream endlessly.
+type InfiniteLoop struct {
+	src  io.ReadSeeker
+	size int64
+	pos  int64
+}
+
+// NewInfiniteLoop creates a new InfiniteLoop.
+// size is the byte size of the part of src to repeat.
+func NewInfiniteLoop(src io.ReadSeeker, size int64) *InfiniteLoop {
+	return &InfiniteLoop{
+		src:  src,
+		size: size,
+	}
+}
+
+func (l *InfiniteLoop) Read(b []byte) (int, error) {
+	if l.size <= 0 {
+		return 0, io.EOF
+	}
+	if l.pos == l.size {
+		if _, err := l.src.Seek(0, 0); err != nil {
+			return 0, err
+		}
+		l.pos = 0
+	}
+	if rest := l.size - l.pos; rest < int64(len(b)) {
+		b = b[:rest]
+	}
+	n, err := l.src.Read(b)
+	l.pos += int64(n)
+	if err == io.EOF {
+		// The source is shorter than the size. Repeat from the beginning.
+		l.size = l.pos
+		err = nil
+	}
+	return n, err
+}
+
+func (l *InfiniteLoop) Seek(offset int64, whence int) (int64, error) {
+	if l.size <= 0 {
+		return 0, nil
+	}
+	switch whence {
+	case 0:
+		l.pos = offset
+	case 1:
+		l.pos += offset
+	case 2:
+		l.pos = l.size + offset
+	}
+	l.pos %= l.size
+	if l.pos < 0 {
+		l.pos += l.size
+	}
+	return l.src.Seek(l.pos, 0)
+}
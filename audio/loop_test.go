@@ -0,0 +1,81 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestInfiniteLoop(t *testing.T) {
+	l := NewInfiniteLoop(bytes.NewReader([]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}), 8)
+	got := make([]byte, 20)
+	if _, err := io.ReadFull(l, got); err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{0, 1, 2, 3, 4, 5, 6, 7, 0, 1, 2, 3, 4, 5, 6, 7, 0, 1, 2, 3}
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+
+	testCases := []struct {
+		offset int64
+		whence int
+		pos    int64
+		want   []byte
+	}{
+		{3, 0, 3, []byte{3, 4, 5, 6, 7, 0}},
+		{-1, 2, 7, []byte{7, 0, 1}},
+		{2, 1, 4, []byte{4, 5}},
+		{17, 0, 1, []byte{1, 2}},
+	}
+	for _, c := range testCases {
+		pos, err := l.Seek(c.offset, c.whence)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pos != c.pos {
+			t.Errorf("Seek(%d, %d): got %d; want %d", c.offset, c.whence, pos, c.pos)
+		}
+		got := make([]byte, len(c.want))
+		if _, err := io.ReadFull(l, got); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, c.want) {
+			t.Errorf("after Seek(%d, %d): got %v; want %v", c.offset, c.whence, got, c.want)
+		}
+	}
+}
+
+func TestInfiniteLoopShortSource(t *testing.T) {
+	// The source is shorter than the size.
+	l := NewInfiniteLoop(bytes.NewReader([]byte{0, 1, 2, 3}), 8)
+	got := make([]byte, 10)
+	if _, err := io.ReadFull(l, got); err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{0, 1, 2, 3, 0, 1, 2, 3, 0, 1}
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func TestInfiniteLoopEmpty(t *testing.T) {
+	l := NewInfiniteLoop(bytes.NewReader(nil), 0)
+	if _, err := l.Read(make([]byte, 4)); err != io.EOF {
+		t.Errorf("Read: got %v; want io.EOF", err)
+	}
+}
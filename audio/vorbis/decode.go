@@ -0,0 +1,167 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vorbis provides Ogg Vorbis decoder.
+package vorbis
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hajimehoshi/ebiten/audio"
+	"github.com/hajimehoshi/ebiten/audio/internal/convert"
+	"github.com/hajimehoshi/ebiten/audio/internal/oggvorbis"
+	"io"
+	"math"
+)
+
+// Stream is a decoded stream.
+type Stream struct {
+	src  audio.ReadSeekCloser
+	size int64
+}
+
+// Read is implementation of io.Reader's Read.
+func (s *Stream) Read(p []byte) (int, error) {
+	return s.src.Read(p)
+}
+
+// Seek is implementation of io.Seeker's Seek.
+func (s *Stream) Seek(offset int64, whence int) (int64, error) {
+	return s.src.Seek(offset, whence)
+}
+
+// Close is implementation of io.Closer's Close.
+func (s *Stream) Close() error {
+	return s.src.Close()
+}
+
+// Size returns the size of decoded stream in bytes.
+func (s *Stream) Size() int64 {
+	return s.size
+}
+
+// stream converts the decoded float samples into 16-bit stereo PCM.
+type stream struct {
+	src     audio.ReadSeekCloser
+	decoder *oggvorbis.Reader
+	frames  int64
+	pos     int64
+	buf     []float32
+}
+
+func (s *stream) size() int64 {
+	return s.frames * 4
+}
+
+func (s *stream) Read(p []byte) (int, error) {
+	if s.size() <= s.pos {
+		return 0, io.EOF
+	}
+	ch := s.decoder.Channels()
+	frames := len(p) / 4
+	if rest := int((s.size() - s.pos) / 4); rest < frames {
+		frames = rest
+	}
+	if len(s.buf) < frames*ch {
+		s.buf = make([]float32, frames*ch)
+	}
+	n, err := s.decoder.Read(s.buf[:frames*ch])
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+	frames = n / ch
+	if frames == 0 && err == io.EOF {
+		return 0, io.EOF
+	}
+	for i := 0; i < frames; i++ {
+		l := toInt16(s.buf[i*ch])
+		r := l
+		if 2 <= ch {
+			r = toInt16(s.buf[i*ch+1])
+		}
+		p[4*i] = uint8(l)
+		p[4*i+1] = uint8(l >> 8)
+		p[4*i+2] = uint8(r)
+		p[4*i+3] = uint8(r >> 8)
+	}
+	s.pos += int64(frames) * 4
+	return frames * 4, nil
+}
+
+func toInt16(v float32) int16 {
+	return int16(math.Max(math.Min(float64(v)*(1<<15), math.MaxInt16), math.MinInt16))
+}
+
+func (s *stream) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case 0:
+	case 1:
+		offset += s.pos
+	case 2:
+		offset += s.size()
+	}
+	offset = offset / 4 * 4
+	if offset < 0 {
+		offset = 0
+	}
+	if err := s.decoder.SetPosition(offset / 4); err != nil {
+		return 0, err
+	}
+	s.pos = offset
+	return s.pos, nil
+}
+
+func (s *stream) Close() error {
+	return s.src.Close()
+}
+
+// Decode decodes Ogg Vorbis data to playable stream.
+//
+// The stream is converted to stereo and resampled to the context's sample rate if needed.
+func Decode(context *audio.Context, src audio.ReadSeekCloser) (*Stream, error) {
+	return decode(src, context.SampleRate())
+}
+
+// decode decodes Ogg Vorbis data to a stream at the given sample rate.
+func decode(src audio.ReadSeekCloser, sampleRate int) (*Stream, error) {
+	decoder, err := oggvorbis.NewReader(src)
+	if err != nil {
+		return nil, err
+	}
+	if ch := decoder.Channels(); ch < 1 {
+		return nil, errors.New(fmt.Sprintf("vorbis: invalid channel num: %d", ch))
+	}
+	s := &stream{
+		src:     src,
+		decoder: decoder,
+		frames:  decoder.Length(),
+	}
+
+	var r audio.ReadSeekCloser = s
+	size := s.size()
+	if srcSampleRate := decoder.SampleRate(); srcSampleRate != sampleRate {
+		res, err := convert.NewResampling(s, size, srcSampleRate, sampleRate)
+		if err != nil {
+			return nil, err
+		}
+		r = &readSeekCloser{res, s}
+		size = res.Size()
+	}
+	return &Stream{src: r, size: size}, nil
+}
+
+type readSeekCloser struct {
+	io.ReadSeeker
+	io.Closer
+}
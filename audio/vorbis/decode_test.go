@@ -0,0 +1,105 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vorbis
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+type bytesReadSeekCloser struct {
+	*bytes.Reader
+}
+
+func (b *bytesReadSeekCloser) Close() error {
+	return nil
+}
+
+// testdata/test.ogg is a mono stream of 44100 frames at 44100 Hz, taken from github.com/jfreymuth/oggvorbis.
+func testOgg(t *testing.T) *bytesReadSeekCloser {
+	b, err := ioutil.ReadFile("testdata/test.ogg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &bytesReadSeekCloser{bytes.NewReader(b)}
+}
+
+func TestDecode(t *testing.T) {
+	// The decoder is tested without a context not to open the actual output device.
+	s, err := decode(testOgg(t), 44100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.Size(), int64(4*44100); got != want {
+		t.Errorf("s.Size(): got %d; want %d", got, want)
+	}
+	all, err := ioutil.ReadAll(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(all), 4*44100; got != want {
+		t.Fatalf("len(data): got %d; want %d", got, want)
+	}
+	silent := true
+	for i := 0; i < len(all); i += 4 {
+		// The mono channel is copied to both channels.
+		if all[i] != all[i+2] || all[i+1] != all[i+3] {
+			t.Fatalf("frame %d: left and right must be the same", i/4)
+		}
+		if all[i] != 0 || all[i+1] != 0 {
+			silent = false
+		}
+	}
+	if silent {
+		t.Errorf("decoded data must not be silent")
+	}
+
+	// Seek
+	if _, err := s.Seek(4*1000, 0); err != nil {
+		t.Fatal(err)
+	}
+	got := make([]byte, 4*100)
+	if _, err := s.Read(got); err != nil {
+		t.Fatal(err)
+	}
+	if want := all[4*1000 : 4*1100]; !bytes.Equal(got, want) {
+		t.Errorf("after seeking: got %v; want %v", got, want)
+	}
+}
+
+func TestDecodeWithResampling(t *testing.T) {
+	s, err := decode(testOgg(t), 22050)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.Size(), int64(4*22050); got != want {
+		t.Errorf("s.Size(): got %d; want %d", got, want)
+	}
+	all, err := ioutil.ReadAll(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(all), 4*22050; got != want {
+		t.Errorf("len(data): got %d; want %d", got, want)
+	}
+}
+
+func TestDecodeInvalidData(t *testing.T) {
+	src := &bytesReadSeekCloser{bytes.NewReader([]byte("not an ogg stream"))}
+	if _, err := decode(src, 44100); err == nil {
+		t.Errorf("decode must return an error for invalid data")
+	}
+}
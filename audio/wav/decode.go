@@ -0,0 +1,226 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package wav provides WAV (RIFF) decoder.
+package wav
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/hajimehoshi/ebiten/audio"
+	"github.com/hajimehoshi/ebiten/audio/internal/convert"
+	"io"
+)
+
+// Stream is a decoded stream.
+type Stream struct {
+	src  audio.ReadSeekCloser
+	size int64
+}
+
+// Read is implementation of io.Reader's Read.
+func (s *Stream) Read(p []byte) (int, error) {
+	return s.src.Read(p)
+}
+
+// Seek is implementation of io.Seeker's Seek.
+func (s *Stream) Seek(offset int64, whence int) (int64, error) {
+	return s.src.Seek(offset, whence)
+}
+
+// Close is implementation of io.Closer's Close.
+func (s *Stream) Close() error {
+	return s.src.Close()
+}
+
+// Size returns the size of decoded stream in bytes.
+func (s *Stream) Size() int64 {
+	return s.size
+}
+
+// stream converts 8/16-bit mono/stereo PCM into 16-bit stereo PCM.
+type stream struct {
+	src           audio.ReadSeekCloser
+	headerSize    int64
+	dataSize      int64
+	channelNum    int
+	bitsPerSample int
+	pos           int64
+}
+
+func (s *stream) srcBytesPerFrame() int64 {
+	return int64(s.channelNum * s.bitsPerSample / 8)
+}
+
+func (s *stream) size() int64 {
+	return s.dataSize / s.srcBytesPerFrame() * 4
+}
+
+func (s *stream) Read(p []byte) (int, error) {
+	if s.size() <= s.pos {
+		return 0, io.EOF
+	}
+	frames := int64(len(p) / 4)
+	if rest := (s.size() - s.pos) / 4; rest < frames {
+		frames = rest
+	}
+	buf := make([]byte, frames*s.srcBytesPerFrame())
+	n, err := io.ReadFull(s.src, buf)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return 0, err
+	}
+	frames = int64(n) / s.srcBytesPerFrame()
+	for i := int64(0); i < frames; i++ {
+		var l, r int16
+		f := buf[i*s.srcBytesPerFrame():]
+		switch s.bitsPerSample {
+		case 8:
+			// 8-bit samples are unsigned.
+			l = (int16(f[0]) - 128) << 8
+			r = l
+			if s.channelNum == 2 {
+				r = (int16(f[1]) - 128) << 8
+			}
+		case 16:
+			l = int16(f[0]) | int16(f[1])<<8
+			r = l
+			if s.channelNum == 2 {
+				r = int16(f[2]) | int16(f[3])<<8
+			}
+		}
+		p[4*i] = uint8(l)
+		p[4*i+1] = uint8(l >> 8)
+		p[4*i+2] = uint8(r)
+		p[4*i+3] = uint8(r >> 8)
+	}
+	s.pos += frames * 4
+	return int(frames * 4), nil
+}
+
+func (s *stream) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case 0:
+	case 1:
+		offset += s.pos
+	case 2:
+		offset += s.size()
+	}
+	offset = offset / 4 * 4
+	if offset < 0 {
+		offset = 0
+	}
+	if _, err := s.src.Seek(s.headerSize+offset/4*s.srcBytesPerFrame(), 0); err != nil {
+		return 0, err
+	}
+	s.pos = offset
+	return s.pos, nil
+}
+
+func (s *stream) Close() error {
+	return s.src.Close()
+}
+
+// Decode decodes WAV (RIFF) data to playable stream.
+//
+// The format must be linear PCM with 8 or 16 bits per sample and 1 or 2 channels.
+// The stream is resampled to the context's sample rate if needed.
+func Decode(context *audio.Context, src audio.ReadSeekCloser) (*Stream, error) {
+	return decode(src, context.SampleRate())
+}
+
+// decode decodes WAV data to a stream at the given sample rate.
+func decode(src audio.ReadSeekCloser, sampleRate int) (*Stream, error) {
+	buf := make([]byte, 12)
+	if _, err := io.ReadFull(src, buf); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(buf[0:4], []byte("RIFF")) {
+		return nil, errors.New("wav: invalid header: 'RIFF' not found")
+	}
+	if !bytes.Equal(buf[8:12], []byte("WAVE")) {
+		return nil, errors.New("wav: invalid header: 'WAVE' not found")
+	}
+
+	s := &stream{src: src}
+	srcSampleRate := 0
+	headerSize := int64(len(buf))
+chunks:
+	for {
+		buf := make([]byte, 8)
+		if _, err := io.ReadFull(src, buf); err != nil {
+			return nil, err
+		}
+		headerSize += 8
+		size := int64(buf[4]) | int64(buf[5])<<8 | int64(buf[6])<<16 | int64(buf[7])<<24
+		switch {
+		case bytes.Equal(buf[0:4], []byte("fmt ")):
+			if size < 16 {
+				return nil, errors.New("wav: invalid header: too short fmt chunk")
+			}
+			buf := make([]byte, size)
+			if _, err := io.ReadFull(src, buf); err != nil {
+				return nil, err
+			}
+			format := int(buf[0]) | int(buf[1])<<8
+			if format != 1 {
+				return nil, errors.New(fmt.Sprintf("wav: format must be linear PCM (1) but %d", format))
+			}
+			s.channelNum = int(buf[2]) | int(buf[3])<<8
+			if s.channelNum != 1 && s.channelNum != 2 {
+				return nil, errors.New(fmt.Sprintf("wav: channel num must be 1 or 2 but %d", s.channelNum))
+			}
+			srcSampleRate = int(buf[4]) | int(buf[5])<<8 | int(buf[6])<<16 | int(buf[7])<<24
+			if srcSampleRate <= 0 {
+				return nil, errors.New(fmt.Sprintf("wav: invalid sample rate: %d", srcSampleRate))
+			}
+			s.bitsPerSample = int(buf[14]) | int(buf[15])<<8
+			if s.bitsPerSample != 8 && s.bitsPerSample != 16 {
+				return nil, errors.New(fmt.Sprintf("wav: bits per sample must be 8 or 16 but %d", s.bitsPerSample))
+			}
+			headerSize += size
+		case bytes.Equal(buf[0:4], []byte("data")):
+			s.dataSize = size
+			break chunks
+		default:
+			// Chunks are aligned to 2 bytes.
+			size += size % 2
+			if _, err := src.Seek(size, 1); err != nil {
+				return nil, err
+			}
+			headerSize += size
+		}
+	}
+	if s.channelNum == 0 {
+		return nil, errors.New("wav: invalid header: fmt chunk not found")
+	}
+	s.headerSize = headerSize
+
+	var r audio.ReadSeekCloser = s
+	size := s.size()
+	if srcSampleRate != sampleRate {
+		res, err := convert.NewResampling(s, size, srcSampleRate, sampleRate)
+		if err != nil {
+			return nil, err
+		}
+		r = &readSeekCloser{res, s}
+		size = res.Size()
+	}
+	return &Stream{src: r, size: size}, nil
+}
+
+type readSeekCloser struct {
+	io.ReadSeeker
+	io.Closer
+}
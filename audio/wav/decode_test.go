@@ -0,0 +1,104 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wav
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/hajimehoshi/ebiten/audio"
+	"io/ioutil"
+	"testing"
+)
+
+type bytesReadSeekCloser struct {
+	*bytes.Reader
+}
+
+func (b *bytesReadSeekCloser) Close() error {
+	return nil
+}
+
+func wavData(sampleRate, channelNum, bitsPerSample int, data []byte) audio.ReadSeekCloser {
+	b := &bytes.Buffer{}
+	b.WriteString("RIFF")
+	binary.Write(b, binary.LittleEndian, uint32(4+8+16+8+len(data)))
+	b.WriteString("WAVE")
+	b.WriteString("fmt ")
+	binary.Write(b, binary.LittleEndian, uint32(16))
+	binary.Write(b, binary.LittleEndian, uint16(1))
+	binary.Write(b, binary.LittleEndian, uint16(channelNum))
+	binary.Write(b, binary.LittleEndian, uint32(sampleRate))
+	binary.Write(b, binary.LittleEndian, uint32(sampleRate*channelNum*bitsPerSample/8))
+	binary.Write(b, binary.LittleEndian, uint16(channelNum*bitsPerSample/8))
+	binary.Write(b, binary.LittleEndian, uint16(bitsPerSample))
+	b.WriteString("data")
+	binary.Write(b, binary.LittleEndian, uint32(len(data)))
+	b.Write(data)
+	return &bytesReadSeekCloser{bytes.NewReader(b.Bytes())}
+}
+
+func TestDecode(t *testing.T) {
+	// The decoder is tested without a context not to open the actual output device.
+	// 8-bit mono
+	s, err := decode(wavData(22050, 1, 8, []byte{0x80, 0xff, 0x00}), 22050)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.Size(), int64(12); got != want {
+		t.Errorf("s.Size(): got %d; want %d", got, want)
+	}
+	got, err := ioutil.ReadAll(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{0, 0, 0, 0, 0x00, 0x7f, 0x00, 0x7f, 0x00, 0x80, 0x00, 0x80}
+	if !bytes.Equal(got, want) {
+		t.Errorf("8-bit mono: got %v; want %v", got, want)
+	}
+
+	// Seek
+	if _, err := s.Seek(8, 0); err != nil {
+		t.Fatal(err)
+	}
+	got, err = ioutil.ReadAll(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want[8:]) {
+		t.Errorf("after seeking: got %v; want %v", got, want[8:])
+	}
+
+	// 16-bit stereo with resampling
+	s, err = decode(wavData(44100, 2, 16, make([]byte, 4*100)), 22050)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.Size(), int64(4*50); got != want {
+		t.Errorf("s.Size(): got %d; want %d", got, want)
+	}
+	got, err = ioutil.ReadAll(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 4*50 {
+		t.Errorf("len(resampled data): got %d; want %d", len(got), 4*50)
+	}
+}
+
+func TestDecodeInvalidSampleRate(t *testing.T) {
+	if _, err := decode(wavData(0, 2, 16, make([]byte, 4*100)), 44100); err == nil {
+		t.Errorf("decode must return an error for the sample rate 0")
+	}
+}
@@ -23,4 +23,9 @@
 //     func main() {
 //         ebiten.Run(update, 320, 240, 2, "Your game's title")
 //     }
+//
+// With the build tag headless, Ebiten uses a software rasterizer instead of OpenGL,
+// and neither a window nor a GPU is required. This is useful for testing:
+//
+//     go test -tags headless ./...
 package ebiten
@@ -0,0 +1,71 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build js
+
+package ebitenutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// A ReadSeekCloser is the interface that groups the basic Read, Seek and Close methods.
+type ReadSeekCloser interface {
+	Read(p []byte) (int, error)
+	Seek(offset int64, whence int) (int64, error)
+	Close() error
+}
+
+type file struct {
+	*bytes.Reader
+}
+
+func (f *file) Close() error {
+	return nil
+}
+
+// OpenFile opens a file via XMLHttpRequest and returns a stream for its data.
+func OpenFile(path string) (ReadSeekCloser, error) {
+	var err error
+	var content js.Object
+	ch := make(chan struct{})
+	req := js.Global.Get("XMLHttpRequest").New()
+	req.Call("open", "GET", path, true)
+	req.Set("responseType", "arraybuffer")
+	req.Call("addEventListener", "load", func() {
+		defer close(ch)
+		status := req.Get("status").Int()
+		if 200 <= status && status < 400 {
+			content = req.Get("response")
+			return
+		}
+		err = errors.New(fmt.Sprintf("http error: %d", status))
+	})
+	req.Call("addEventListener", "error", func() {
+		defer close(ch)
+		// TODO: Add more information.
+		err = errors.New("http error")
+	})
+	req.Call("send")
+	<-ch
+	if err != nil {
+		return nil, err
+	}
+
+	data := js.Global.Get("Uint8Array").New(content).Interface().([]uint8)
+	return &file{bytes.NewReader(data)}, nil
+}
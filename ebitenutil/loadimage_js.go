@@ -17,44 +17,17 @@
 package ebitenutil
 
 import (
-	"bytes"
-	"errors"
-	"fmt"
-	"github.com/gopherjs/gopherjs/js"
 	"github.com/hajimehoshi/ebiten"
 	"image"
 )
 
 func NewImageFromFile(path string, filter ebiten.Filter) (*ebiten.Image, image.Image, error) {
-	var err error
-	var content js.Object
-	ch := make(chan struct{})
-	req := js.Global.Get("XMLHttpRequest").New()
-	req.Call("open", "GET", path, true)
-	req.Set("responseType", "arraybuffer")
-	req.Call("addEventListener", "load", func() {
-		defer close(ch)
-		status := req.Get("status").Int()
-		if 200 <= status && status < 400 {
-			content = req.Get("response")
-			return
-		}
-		err = errors.New(fmt.Sprintf("http error: %d", status))
-	})
-	req.Call("addEventListener", "error", func() {
-		defer close(ch)
-		// TODO: Add more information.
-		err = errors.New("http error")
-	})
-	req.Call("send")
-	<-ch
+	file, err := OpenFile(path)
 	if err != nil {
 		return nil, nil, err
 	}
-
-	data := js.Global.Get("Uint8Array").New(content).Interface().([]uint8)
-	b := bytes.NewBuffer(data)
-	img, _, err := image.Decode(b)
+	defer file.Close()
+	img, _, err := image.Decode(file)
 	if err != nil {
 		return nil, nil, err
 	}
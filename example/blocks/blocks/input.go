@@ -27,19 +27,19 @@ var gamepadStdButtons = []gamepad.StdButton{
 	gamepad.StdButtonRR,
 }
 
+const gamepadID = 0
+
 type Input struct {
-	keyStates              [256]int
-	gamepadButtonStates    [256]int
 	gamepadStdButtonStates [16]int
 	gamepadConfig          gamepad.Configuration
 }
 
 func (i *Input) StateForKey(key ebiten.Key) int {
-	return i.keyStates[key]
+	return ebiten.KeyPressDuration(key)
 }
 
 func (i *Input) StateForGamepadButton(b ebiten.GamepadButton) int {
-	return i.gamepadButtonStates[b]
+	return ebiten.GamepadButtonPressDuration(gamepadID, b)
 }
 
 func (i *Input) stateForGamepadStdButton(b gamepad.StdButton) int {
@@ -47,23 +47,6 @@ func (i *Input) stateForGamepadStdButton(b gamepad.StdButton) int {
 }
 
 func (i *Input) Update() {
-	for key := range i.keyStates {
-		if !ebiten.IsKeyPressed(ebiten.Key(key)) {
-			i.keyStates[key] = 0
-			continue
-		}
-		i.keyStates[key]++
-	}
-
-	const gamepadID = 0
-	for b := range i.gamepadButtonStates {
-		if !ebiten.IsGamepadButtonPressed(gamepadID, ebiten.GamepadButton(b)) {
-			i.gamepadButtonStates[b] = 0
-			continue
-		}
-		i.gamepadButtonStates[b]++
-	}
-
 	for _, b := range gamepadStdButtons {
 		if !i.gamepadConfig.IsButtonPressed(gamepadID, b) {
 			i.gamepadStdButtonStates[b] = 0
@@ -0,0 +1,185 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shape
+
+import (
+	"github.com/hajimehoshi/ebiten"
+	"image/color"
+	"math"
+)
+
+// tolerance is the maximum distance in pixels between the curves and the approximating segments.
+const tolerance = 0.25
+
+// segmentNum returns the number of segments to approximate an arc with the radius r and the angle.
+// Larger arcs are split into more segments.
+func segmentNum(r, angle float64) int {
+	if r <= tolerance {
+		return 4
+	}
+	step := 2 * math.Acos(1-tolerance/r)
+	return int(math.Max(math.Ceil(math.Abs(angle)/step), 4))
+}
+
+// addArc adds the points of the elliptic arc from angle0 to angle1 to the path.
+// If the path has no current point, the first point starts a new subpath.
+func addArc(p *ebiten.Path, cx, cy, rx, ry, angle0, angle1 float64) {
+	n := segmentNum(math.Max(rx, ry), angle1-angle0)
+	for i := 0; i <= n; i++ {
+		theta := angle0 + (angle1-angle0)*float64(i)/float64(n)
+		sin, cos := math.Sincos(theta)
+		p.LineTo(cx+rx*cos, cy+ry*sin)
+	}
+}
+
+func sameColor(c0, c1 color.Color) bool {
+	r0, g0, b0, a0 := c0.RGBA()
+	r1, g1, b1, a1 := c1.RGBA()
+	return r0 == r1 && g0 == g1 && b0 == b1 && a0 == a1
+}
+
+// drawShapes adds a shape for each rectangle to paths and draws them by draw.
+// Consecutive rectangles with the same color are drawn at once.
+func drawShapes(rects ebiten.Rects, add func(p *ebiten.Path, x, y, width, height float64), draw func(p *ebiten.Path, clr color.Color) error) error {
+	var path *ebiten.Path
+	var clr color.Color
+	for i := 0; i < rects.Len(); i++ {
+		c := rects.Color(i)
+		if path != nil && !sameColor(c, clr) {
+			if err := draw(path, clr); err != nil {
+				return err
+			}
+			path = nil
+		}
+		if path == nil {
+			path = &ebiten.Path{}
+			clr = c
+		}
+		x, y, w, h := rects.Rect(i)
+		if w <= 0 || h <= 0 {
+			continue
+		}
+		add(path, float64(x), float64(y), float64(w), float64(h))
+	}
+	if path == nil {
+		return nil
+	}
+	return draw(path, clr)
+}
+
+func fillShapes(s *ebiten.Image, rects ebiten.Rects, add func(p *ebiten.Path, x, y, width, height float64)) error {
+	return drawShapes(rects, add, func(p *ebiten.Path, clr color.Color) error {
+		return s.FillPath(p, clr, nil)
+	})
+}
+
+// FillEllipse draws a filled ellipse inscribed in the rectangle.
+func FillEllipse(s *ebiten.Image, x, y, width, height int, clr color.Color) error {
+	return FillEllipses(s, &rect{x, y, width, height, clr})
+}
+
+// FillEllipses draws filled ellipses inscribed in the rectangles.
+func FillEllipses(s *ebiten.Image, rects ebiten.Rects) error {
+	return fillShapes(s, rects, func(p *ebiten.Path, x, y, w, h float64) {
+		p.MoveTo(x+w, y+h/2)
+		addArc(p, x+w/2, y+h/2, w/2, h/2, 0, 2*math.Pi)
+		p.Close()
+	})
+}
+
+// FillPie draws a filled pie slice of the ellipse inscribed in the rectangle.
+//
+// The slice is from angle0 to angle1 in radians, clockwise from the positive x-axis.
+func FillPie(s *ebiten.Image, x, y, width, height int, angle0, angle1 float64, clr color.Color) error {
+	return FillPies(s, &rect{x, y, width, height, clr}, angle0, angle1)
+}
+
+// FillPies draws filled pie slices of the ellipses inscribed in the rectangles.
+func FillPies(s *ebiten.Image, rects ebiten.Rects, angle0, angle1 float64) error {
+	return fillShapes(s, rects, func(p *ebiten.Path, x, y, w, h float64) {
+		p.MoveTo(x+w/2, y+h/2)
+		addArc(p, x+w/2, y+h/2, w/2, h/2, angle0, angle1)
+		p.Close()
+	})
+}
+
+// FillRing draws a segment of the ring inscribed in the rectangle.
+//
+// thickness is the width of the ring. The segment is from angle0 to angle1 in radians
+// as with FillPie. For a whole ring, specify 0 and 2π.
+func FillRing(s *ebiten.Image, x, y, width, height, thickness int, angle0, angle1 float64, clr color.Color) error {
+	return FillRings(s, &rect{x, y, width, height, clr}, thickness, angle0, angle1)
+}
+
+// FillRings draws segments of the rings inscribed in the rectangles.
+func FillRings(s *ebiten.Image, rects ebiten.Rects, thickness int, angle0, angle1 float64) error {
+	t := float64(thickness)
+	return fillShapes(s, rects, func(p *ebiten.Path, x, y, w, h float64) {
+		cx, cy := x+w/2, y+h/2
+		sin, cos := math.Sincos(angle0)
+		p.MoveTo(cx+w/2*cos, cy+h/2*sin)
+		addArc(p, cx, cy, w/2, h/2, angle0, angle1)
+		// The inner arc is in the opposite direction so that the whole ring has a hole.
+		addArc(p, cx, cy, math.Max(w/2-t, 0), math.Max(h/2-t, 0), angle1, angle0)
+		p.Close()
+	})
+}
+
+// addRoundedRect adds the rounded rectangle to the path as a closed subpath.
+func addRoundedRect(p *ebiten.Path, x, y, w, h, r float64) {
+	r = math.Max(math.Min(r, math.Min(w, h)/2), 0)
+	p.MoveTo(x+r, y)
+	if r == 0 {
+		p.LineTo(x+w, y)
+		p.LineTo(x+w, y+h)
+		p.LineTo(x, y+h)
+		p.Close()
+		return
+	}
+	addArc(p, x+w-r, y+r, r, r, -math.Pi/2, 0)
+	addArc(p, x+w-r, y+h-r, r, r, 0, math.Pi/2)
+	addArc(p, x+r, y+h-r, r, r, math.Pi/2, math.Pi)
+	addArc(p, x+r, y+r, r, r, math.Pi, 3*math.Pi/2)
+	p.Close()
+}
+
+// FillRoundedRect draws a filled rectangle with the corners rounded by the radius.
+func FillRoundedRect(s *ebiten.Image, x, y, width, height, radius int, clr color.Color) error {
+	return FillRoundedRects(s, &rect{x, y, width, height, clr}, radius)
+}
+
+// FillRoundedRects draws filled rectangles with the corners rounded by the radius.
+func FillRoundedRects(s *ebiten.Image, rects ebiten.Rects, radius int) error {
+	return fillShapes(s, rects, func(p *ebiten.Path, x, y, w, h float64) {
+		addRoundedRect(p, x, y, w, h, float64(radius))
+	})
+}
+
+// DrawRoundedRect draws the outline of a rectangle with the corners rounded by the radius.
+//
+// As ebiten.Image.DrawRect does, the outline is 1 pixel wide and inside the rectangle.
+func DrawRoundedRect(s *ebiten.Image, x, y, width, height, radius int, clr color.Color) error {
+	return DrawRoundedRects(s, &rect{x, y, width, height, clr}, radius)
+}
+
+// DrawRoundedRects draws the outlines of rectangles with the corners rounded by the radius.
+func DrawRoundedRects(s *ebiten.Image, rects ebiten.Rects, radius int) error {
+	add := func(p *ebiten.Path, x, y, w, h float64) {
+		addRoundedRect(p, x+0.5, y+0.5, w-1, h-1, float64(radius)-0.5)
+	}
+	return drawShapes(rects, add, func(p *ebiten.Path, clr color.Color) error {
+		return s.StrokePath(p, clr, nil, nil)
+	})
+}
@@ -0,0 +1,150 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shape
+
+import (
+	"github.com/hajimehoshi/ebiten"
+	"image/color"
+	"math"
+	"testing"
+)
+
+var white = color.RGBA{0xff, 0xff, 0xff, 0xff}
+
+// checkPixels checks the pixels of img at the points. want is the expected opaque-or-not state of each point.
+func checkPixels(t *testing.T, name string, img *ebiten.Image, want map[[2]int]bool) {
+	for p, filled := range want {
+		got := color.RGBAModel.Convert(img.At(p[0], p[1])).(color.RGBA)
+		if filled && got != white {
+			t.Errorf("%s: img.At(%d, %d): got %#v, want: %#v", name, p[0], p[1], got, white)
+		}
+		if !filled && got != (color.RGBA{}) {
+			t.Errorf("%s: img.At(%d, %d): got %#v, want: transparent", name, p[0], p[1], got)
+		}
+	}
+}
+
+func TestSegmentNum(t *testing.T) {
+	if got := segmentNum(tolerance/2, 2*math.Pi); got != 4 {
+		t.Errorf("segmentNum(%f, 2π): got %d, want: 4", tolerance/2, got)
+	}
+	prev := 0
+	for _, r := range []float64{1, 4, 16, 64, 256} {
+		n := segmentNum(r, 2*math.Pi)
+		if n <= prev {
+			t.Errorf("segmentNum(%f, 2π): got %d, want: more than %d", r, n, prev)
+		}
+		prev = n
+		// Each segment must be within the tolerance from the arc.
+		if d := r * (1 - math.Cos(math.Pi/float64(n))); tolerance+1e-9 < d {
+			t.Errorf("segmentNum(%f, 2π): %d: the deviation %f is over the tolerance", r, n, d)
+		}
+		// A half arc needs about half of the segments. The angle's sign doesn't matter.
+		if got, want := segmentNum(r, -math.Pi), int(math.Max(math.Ceil(float64(n)/2), 4)); got < want-1 || want+1 < got {
+			t.Errorf("segmentNum(%f, -π): got %d, want: about %d", r, got, want)
+		}
+	}
+}
+
+func TestFillRingHole(t *testing.T) {
+	img, err := ebiten.NewImage(16, 16, ebiten.FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	// The inner arc must be in the opposite direction to the outer arc,
+	// or the non-zero fill rule fills the hole.
+	if err := FillRing(img, 0, 0, 16, 16, 4, 0, 2*math.Pi, white); err != nil {
+		t.Fatal(err)
+		return
+	}
+	checkPixels(t, "ring", img, map[[2]int]bool{
+		{7, 7}:  false,
+		{8, 8}:  false,
+		{5, 7}:  false,
+		{1, 7}:  true,
+		{14, 8}: true,
+		{7, 1}:  true,
+		{8, 14}: true,
+		{0, 0}:  false,
+	})
+}
+
+func TestFillPieOrientation(t *testing.T) {
+	img, err := ebiten.NewImage(16, 16, ebiten.FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	// The angles are clockwise from the positive x-axis since y goes down:
+	// 0 to π/2 is the lower right quarter.
+	if err := FillPie(img, 0, 0, 16, 16, 0, math.Pi/2, white); err != nil {
+		t.Fatal(err)
+		return
+	}
+	checkPixels(t, "pie", img, map[[2]int]bool{
+		{11, 11}: true,
+		{11, 4}:  false,
+		{4, 4}:   false,
+		{4, 11}:  false,
+	})
+}
+
+func TestFillRoundedRectRadius(t *testing.T) {
+	cases := []struct {
+		name   string
+		radius int
+		want   map[[2]int]bool
+	}{
+		{
+			// The radius is clamped to half of the shorter side: 2.
+			name:   "large radius",
+			radius: 100,
+			want: map[[2]int]bool{
+				{0, 0}: false,
+				{7, 0}: false,
+				{0, 3}: false,
+				{7, 3}: false,
+				{4, 0}: true,
+				{4, 3}: true,
+				{0, 2}: true,
+				{7, 1}: true,
+			},
+		},
+		{
+			// A negative radius is clamped to 0: a plain rectangle.
+			name:   "negative radius",
+			radius: -3,
+			want: map[[2]int]bool{
+				{0, 0}: true,
+				{7, 0}: true,
+				{0, 3}: true,
+				{7, 3}: true,
+			},
+		},
+	}
+	for _, c := range cases {
+		img, err := ebiten.NewImage(8, 4, ebiten.FilterNearest)
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+		if err := FillRoundedRect(img, 0, 0, 8, 4, c.radius, white); err != nil {
+			t.Fatal(err)
+			return
+		}
+		checkPixels(t, c.name, img, c.want)
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+import (
+	"image"
+)
+
+// These are exported for testing.
+var (
+	ScreenGeometry = screenGeometry
+	Uniforms       = uniforms
+)
+
+// RegionInAtlas returns the region of the image in the atlas page.
+func RegionInAtlas(img *Image) image.Rectangle {
+	return img.region
+}
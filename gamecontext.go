@@ -15,10 +15,13 @@
 package ebiten
 
 import (
+	"errors"
+	"fmt"
 	"github.com/hajimehoshi/ebiten/internal/graphics"
 	"github.com/hajimehoshi/ebiten/internal/opengl"
 	"github.com/hajimehoshi/ebiten/internal/ui"
 	"image"
+	"runtime"
 )
 
 // IsKeyPressed returns a boolean indicating whether key is pressed.
@@ -26,16 +29,117 @@ func IsKeyPressed(key Key) bool {
 	return ui.IsKeyPressed(ui.Key(key))
 }
 
+// IsKeyJustPressed returns a boolean indicating whether key is pressed at the current frame
+// but was not at the previous frame.
+func IsKeyJustPressed(key Key) bool {
+	return ui.KeyPressDuration(ui.Key(key)) == 1
+}
+
+// IsKeyJustReleased returns a boolean indicating whether key is released at the current frame
+// but was pressed at the previous frame.
+func IsKeyJustReleased(key Key) bool {
+	return ui.IsKeyJustReleased(ui.Key(key))
+}
+
+// KeyPressDuration returns the number of frames while key is pressed, including the current frame.
+// KeyPressDuration returns 0 if key is not pressed.
+func KeyPressDuration(key Key) int {
+	return ui.KeyPressDuration(ui.Key(key))
+}
+
+// IsKeyRepeated returns a boolean indicating whether key is pressed at the current frame,
+// or key has been pressed for delay frames and then every interval frames.
+// This emulates the key repeat for e.g. backspace and arrow keys in text input.
+func IsKeyRepeated(key Key, delay, interval int) bool {
+	d := KeyPressDuration(key) - 1
+	if d < 0 {
+		return false
+	}
+	if d == 0 {
+		return true
+	}
+	if d < delay || interval <= 0 {
+		return false
+	}
+	return (d-delay)%interval == 0
+}
+
+// InputChars returns the characters typed since the last frame, in the typed order.
+// Characters composed by IMEs are included when the platform delivers them as typed characters.
+// Control characters like backspace are not included; use IsKeyRepeated for such keys.
+//
+// On browsers, the keyboard focus is on a hidden text field so that IMEs can be used,
+// and the composed text is returned when the composition ends.
+func InputChars() []rune {
+	return ui.InputChars()
+}
+
 // CursorPosition returns a position of a mouse cursor.
 func CursorPosition() (x, y int) {
 	return ui.CursorPosition()
 }
 
+// CursorMovement returns the relative movement of the mouse cursor since the last frame
+// in the screen coordinates.
+func CursorMovement() (dx, dy float64) {
+	return ui.CursorMovement()
+}
+
+// IsCursorInWindow returns a boolean indicating whether the mouse cursor is in the window
+// (or the canvas on browsers).
+func IsCursorInWindow() bool {
+	return ui.IsCursorInWindow()
+}
+
+var cursorMode = CursorModeVisible
+
+// SetCursorMode changes how the mouse cursor is shown.
+// SetCursorMode returns an error when mode is not one of the CursorModes.
+//
+// On browsers, the cursor might be captured at the next click since browsers allow it only by user interactions.
+func SetCursorMode(mode CursorMode) error {
+	if mode < CursorModeVisible || CursorModeCaptured < mode {
+		return errors.New(fmt.Sprintf("ebiten: invalid cursor mode: %d", mode))
+	}
+	cursorMode = mode
+	ui.SetCursorMode(ui.CursorMode(mode))
+	return nil
+}
+
+// CurrentCursorMode returns the current cursor mode.
+func CurrentCursorMode() CursorMode {
+	return cursorMode
+}
+
+// Wheel returns the amount of the mouse wheel scrolled since the last frame, in notches.
+// The positive y is upward.
+func Wheel() (x, y float64) {
+	return ui.Wheel()
+}
+
 // IsMouseButtonPressed returns a boolean indicating whether mouseButton is pressed.
 func IsMouseButtonPressed(mouseButton MouseButton) bool {
 	return ui.IsMouseButtonPressed(ui.MouseButton(mouseButton))
 }
 
+// IsMouseButtonJustPressed returns a boolean indicating whether mouseButton is pressed at the current frame
+// but was not at the previous frame.
+func IsMouseButtonJustPressed(mouseButton MouseButton) bool {
+	return ui.MouseButtonPressDuration(ui.MouseButton(mouseButton)) == 1
+}
+
+// IsMouseButtonJustReleased returns a boolean indicating whether mouseButton is released at the current frame
+// but was pressed at the previous frame.
+func IsMouseButtonJustReleased(mouseButton MouseButton) bool {
+	return ui.IsMouseButtonJustReleased(ui.MouseButton(mouseButton))
+}
+
+// MouseButtonPressDuration returns the number of frames while mouseButton is pressed, including the current frame.
+// MouseButtonPressDuration returns 0 if mouseButton is not pressed.
+func MouseButtonPressDuration(mouseButton MouseButton) int {
+	return ui.MouseButtonPressDuration(ui.MouseButton(mouseButton))
+}
+
 // GamepadAxisNum returns the number of axes of the gamepad.
 //
 // NOTE: Gamepad API is available only on desktops, Chrome and Firefox.
@@ -68,6 +172,33 @@ func IsGamepadButtonPressed(id int, button GamepadButton) bool {
 	return ui.IsGamepadButtonPressed(id, ui.GamepadButton(button))
 }
 
+// IsGamepadButtonJustPressed returns a boolean indicating whether the button is pressed at the current frame
+// but was not at the previous frame.
+//
+// NOTE: Gamepad API is available only on desktops, Chrome and Firefox.
+// To use this API, browsers might require rebooting the browser.
+func IsGamepadButtonJustPressed(id int, button GamepadButton) bool {
+	return ui.GamepadButtonPressDuration(id, ui.GamepadButton(button)) == 1
+}
+
+// IsGamepadButtonJustReleased returns a boolean indicating whether the button is released at the current frame
+// but was pressed at the previous frame.
+//
+// NOTE: Gamepad API is available only on desktops, Chrome and Firefox.
+// To use this API, browsers might require rebooting the browser.
+func IsGamepadButtonJustReleased(id int, button GamepadButton) bool {
+	return ui.IsGamepadButtonJustReleased(id, ui.GamepadButton(button))
+}
+
+// GamepadButtonPressDuration returns the number of frames while the button is pressed, including the current frame.
+// GamepadButtonPressDuration returns 0 if the button is not pressed.
+//
+// NOTE: Gamepad API is available only on desktops, Chrome and Firefox.
+// To use this API, browsers might require rebooting the browser.
+func GamepadButtonPressDuration(id int, button GamepadButton) int {
+	return ui.GamepadButtonPressDuration(id, ui.GamepadButton(button))
+}
+
 // NewImage returns an empty image.
 func NewImage(width, height int, filter Filter) (*Image, error) {
 	var img *Image
@@ -88,6 +219,7 @@ func NewImage(width, height int, filter Filter) (*Image, error) {
 	if err != nil {
 		return nil, err
 	}
+	runtime.SetFinalizer(img, (*Image).finalize)
 	if err := img.Clear(); err != nil {
 		return nil, err
 	}
@@ -114,5 +246,6 @@ func NewImageFromImage(img image.Image, filter Filter) (*Image, error) {
 	if err != nil {
 		return nil, err
 	}
+	runtime.SetFinalizer(eimg, (*Image).finalize)
 	return eimg, nil
 }
@@ -110,7 +110,7 @@ const uiKeysGlfwTmpl = `{{.License}}
 
 // {{.Notice}}
 
-// +build !js
+// +build !js,!headless
 
 package ui
 
@@ -15,6 +15,8 @@
 package ebiten
 
 import (
+	"errors"
+	"fmt"
 	"github.com/hajimehoshi/ebiten/internal/opengl"
 )
 
@@ -27,6 +29,43 @@ const (
 	FilterLinear
 )
 
+// CompositeMode represents how the source colors are blended with the destination colors.
+//
+// In the descriptions below, colors are alpha-premultiplied: s is the source color, d is the destination color,
+// and sa is the source alpha.
+type CompositeMode int
+
+// CompositeModes
+const (
+	// Regular alpha blending: s + d * (1 - sa)
+	CompositeModeSourceOver CompositeMode = CompositeMode(opengl.CompositeModeSourceOver)
+
+	// The destination is replaced with the source: s
+	CompositeModeCopy CompositeMode = CompositeMode(opengl.CompositeModeCopy)
+
+	// The destination is erased where the source is opaque: d * (1 - sa)
+	CompositeModeDestinationOut CompositeMode = CompositeMode(opengl.CompositeModeDestinationOut)
+
+	// Additive blending: s + d
+	CompositeModeLighter CompositeMode = CompositeMode(opengl.CompositeModeLighter)
+
+	// Multiply blending: s * d + d * (1 - sa).
+	// This is the multiply blend mode as long as the destination is opaque.
+	CompositeModeMultiply CompositeMode = CompositeMode(opengl.CompositeModeMultiply)
+
+	// Screen blending: s + d * (1 - s)
+	CompositeModeScreen CompositeMode = CompositeMode(opengl.CompositeModeScreen)
+)
+
+// checkCompositeMode returns an error when mode is not one of the CompositeModes.
+// An unknown mode would otherwise make the GL layer panic when the deferred command is executed.
+func checkCompositeMode(mode CompositeMode) error {
+	if mode < CompositeModeSourceOver || CompositeModeScreen < mode {
+		return errors.New(fmt.Sprintf("ebiten: invalid composite mode: %d", mode))
+	}
+	return nil
+}
+
 func glFilter(c *opengl.Context, filter Filter) opengl.Filter {
 	switch filter {
 	case FilterNearest:
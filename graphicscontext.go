@@ -17,10 +17,13 @@ package ebiten
 import (
 	"github.com/hajimehoshi/ebiten/internal/graphics"
 	"github.com/hajimehoshi/ebiten/internal/opengl"
+	"github.com/hajimehoshi/ebiten/internal/ui"
 )
 
-func newGraphicsContext(c *opengl.Context, screenWidth, screenHeight, screenScale int) (*graphicsContext, error) {
-	f, err := graphics.NewZeroFramebuffer(c, screenWidth*screenScale, screenHeight*screenScale)
+// newGraphicsContext creates a graphics context.
+// The screen is rendered to the default framebuffer of the outside size with screenGeo.
+func newGraphicsContext(c *opengl.Context, screenWidth, screenHeight, outsideWidth, outsideHeight int, screenGeo GeoM) (*graphicsContext, error) {
+	f, err := graphics.NewZeroFramebuffer(c, outsideWidth, outsideHeight)
 	if err != nil {
 		return nil, err
 	}
@@ -35,27 +38,33 @@ func newGraphicsContext(c *opengl.Context, screenWidth, screenHeight, screenScal
 	}
 	screen := &Image{framebuffer: screenF, texture: texture}
 	return &graphicsContext{
-		glContext:   c,
-		defaultR:    &Image{framebuffer: f, texture: nil},
-		screen:      screen,
-		screenScale: screenScale,
+		glContext:     c,
+		defaultR:      &Image{framebuffer: f, texture: nil},
+		screen:        screen,
+		screenGeo:     screenGeo,
+		outsideWidth:  outsideWidth,
+		outsideHeight: outsideHeight,
 	}, nil
 }
 
 type graphicsContext struct {
-	glContext   *opengl.Context
-	screen      *Image
-	defaultR    *Image
-	screenScale int
+	glContext     *opengl.Context
+	screen        *Image
+	defaultR      *Image
+	screenGeo     GeoM
+	outsideWidth  int
+	outsideHeight int
 }
 
-func (c *graphicsContext) dispose() {
-	// NOTE: Now this method is not used anywhere.
+func (c *graphicsContext) dispose() error {
 	framebuffer := c.screen.framebuffer
 	texture := c.screen.texture
+	c.screen.disposed = true
 
-	framebuffer.Dispose(c.glContext)
-	texture.Dispose(c.glContext)
+	if err := framebuffer.Dispose(c.glContext); err != nil {
+		return err
+	}
+	return texture.Dispose(c.glContext)
 }
 
 func (c *graphicsContext) preUpdate() error {
@@ -67,11 +76,14 @@ func (c *graphicsContext) postUpdate() error {
 		return err
 	}
 
-	scale := float64(c.screenScale)
 	options := &DrawImageOptions{}
-	options.GeoM.Scale(scale, scale)
+	options.GeoM = c.screenGeo
 	if err := c.defaultR.DrawImage(c.screen, options); err != nil {
 		return err
 	}
-	return nil
+	var err error
+	ui.Use(func(c *opengl.Context) {
+		err = graphics.FlushCommands(c)
+	})
+	return err
 }
@@ -16,12 +16,14 @@ package ebiten
 
 import (
 	"errors"
+	"fmt"
 	"github.com/hajimehoshi/ebiten/internal"
 	"github.com/hajimehoshi/ebiten/internal/graphics"
 	"github.com/hajimehoshi/ebiten/internal/opengl"
 	"github.com/hajimehoshi/ebiten/internal/ui"
 	"image"
 	"image/color"
+	"runtime"
 )
 
 // Image represents an image.
@@ -31,20 +33,110 @@ type Image struct {
 	framebuffer *graphics.Framebuffer
 	texture     *graphics.Texture
 	pixels      []uint8
+
+	// parent is the image which this image is a part of (e.g. an atlas page or SubImage's receiver).
+	// Such an image shares parent's texture, can be used only as a source, and region is its area in parent.
+	parent *Image
+	region image.Rectangle
+
+	disposed bool
 }
 
+var (
+	errSubImageAsTarget = errors.New("ebiten: a part of another image can't be a render target")
+	errImageDisposed    = errors.New("ebiten: the image is already disposed")
+)
+
 // Size returns the size of the image.
 func (i *Image) Size() (width, height int) {
+	if i.parent != nil {
+		return i.region.Dx(), i.region.Dy()
+	}
 	return i.framebuffer.Size()
 }
 
+// SubImage returns an image representing the portion of the image visible through r.
+//
+// The returned image shares the texture with the receiver and costs no extra VRAM.
+// Size, Bounds and At of the returned image are in its own coordinates: the upper-left is always (0, 0).
+// The returned image can be used only as a source of DrawImage, not as a render target.
+func (i *Image) SubImage(r image.Rectangle) *Image {
+	r = r.Intersect(i.Bounds())
+	root := i
+	if i.parent != nil {
+		root = i.parent
+		r = r.Add(i.region.Min)
+	}
+	return &Image{
+		texture: root.texture,
+		parent:  root,
+		region:  r,
+	}
+}
+
+func (i *Image) isDisposed() bool {
+	if i.parent != nil {
+		return i.disposed || i.parent.disposed
+	}
+	return i.disposed
+}
+
+// checkTarget returns an error if the image can't be a render target.
+func (i *Image) checkTarget() error {
+	if i.isDisposed() {
+		return errImageDisposed
+	}
+	if i.parent != nil {
+		return errSubImageAsTarget
+	}
+	return nil
+}
+
+// Dispose disposes the image data.
+// After disposing, any operations on the image return an error, and At returns a transparent color.
+//
+// The image data is also disposed when the image is garbage-collected,
+// but it is better to call this explicitly since it is not known when the garbage collector runs.
+//
+// Disposing a part of another image (e.g. the result of SubImage) invalidates only the part.
+// Disposing an image invalidates its parts as well.
+func (i *Image) Dispose() (err error) {
+	if i.isDisposed() {
+		return errImageDisposed
+	}
+	i.disposed = true
+	i.pixels = nil
+	if i.parent != nil {
+		return nil
+	}
+	runtime.SetFinalizer(i, nil)
+	ui.Use(func(c *opengl.Context) {
+		if err = i.framebuffer.Dispose(c); err != nil {
+			return
+		}
+		err = i.texture.Dispose(c)
+	})
+	return
+}
+
+// finalize is called when the image is garbage-collected.
+// As finalizers run on an arbitrary goroutine, the deletion is queued and done on the GL goroutine.
+func (i *Image) finalize() {
+	graphics.EnqueueDispose(i.framebuffer, i.texture)
+}
+
 // Clear resets the pixels of the image into 0.
 func (i *Image) Clear() (err error) {
 	return i.Fill(color.Transparent)
 }
 
 // Fill fills the image with a solid color.
+//
+// If the clip rectangle is set, only the rectangle is filled.
 func (i *Image) Fill(clr color.Color) (err error) {
+	if err := i.checkTarget(); err != nil {
+		return err
+	}
 	i.pixels = nil
 	r, g, b, a := internal.RGBA(clr)
 	ui.Use(func(c *opengl.Context) {
@@ -54,6 +146,57 @@ func (i *Image) Fill(clr color.Color) (err error) {
 	return
 }
 
+// SetClip restricts the following drawing on the image to the rectangle r.
+//
+// The clip rectangle applies to all the drawing functions including Fill and Clear, but not to ReplacePixels.
+func (i *Image) SetClip(r image.Rectangle) error {
+	if err := i.checkTarget(); err != nil {
+		return err
+	}
+	i.framebuffer.SetClip(r)
+	return nil
+}
+
+// ResetClip removes the clip rectangle.
+func (i *Image) ResetClip() error {
+	return i.SetClip(i.Bounds())
+}
+
+// ReplacePixels replaces the pixels of the image with p.
+//
+// The pixel format of p is RGBA, alpha-premultiplied (the same as image.RGBA's Pix),
+// and the length of p must be 4 * width * height of the image.
+//
+// This method is useful to update an image's content every frame (e.g. procedural textures or videos)
+// without creating a new image.
+func (i *Image) ReplacePixels(p []uint8) error {
+	return i.ReplacePixelsRect(p, i.Bounds())
+}
+
+// ReplacePixelsRect replaces the pixels of the image in the rectangle r with p.
+//
+// The pixel format is the same as ReplacePixels, and the length of p must be 4 * r.Dx() * r.Dy().
+// r must be inside of the image's bounds.
+func (i *Image) ReplacePixelsRect(p []uint8, r image.Rectangle) (err error) {
+	if err := i.checkTarget(); err != nil {
+		return err
+	}
+	if !r.In(i.Bounds()) {
+		return errors.New("ebiten: the rectangle is out of the image")
+	}
+	if l := 4 * r.Dx() * r.Dy(); len(p) != l {
+		return errors.New(fmt.Sprintf("ebiten: p's length must be %d but %d", l, len(p)))
+	}
+	if r.Empty() {
+		return nil
+	}
+	i.pixels = nil
+	ui.Use(func(c *opengl.Context) {
+		err = i.texture.ReplacePixels(c, p, r.Min.X, r.Min.Y, r.Dx(), r.Dy())
+	})
+	return
+}
+
 // DrawImage draws the given image on the receiver image.
 //
 // This method accepts the options.
@@ -61,36 +204,170 @@ func (i *Image) Fill(clr color.Color) (err error) {
 // After determining parts to draw, this applies the geometry matrix and the color matrix.
 //
 // Here are the default values:
-//     ImageParts: (0, 0) - (source width, source height) to (0, 0) - (source width, source height)
-//                 (i.e. the whole source image)
-//     GeoM:       Identity matrix
-//     ColorM:     Identity matrix (that changes no colors)
-//
-// Be careful that this method is potentially slow.
-// It would be better if you could call this method fewer times.
-func (i *Image) DrawImage(image *Image, options *DrawImageOptions) (err error) {
-	if i == image {
+//     ImageParts:    (0, 0) - (source width, source height) to (0, 0) - (source width, source height)
+//                    (i.e. the whole source image)
+//     GeoM:          Identity matrix
+//     ColorM:        Identity matrix (that changes no colors)
+//     CompositeMode: CompositeModeSourceOver (regular alpha blending)
+//     Shader:        nil (the built-in shader applying ColorM)
+//     Mask:          nil (no mask)
+//
+// If FloatImageParts is specified, it is used instead of ImageParts.
+//
+// If Shader is specified, the source image is rendered by the shader with Uniforms, and ColorM is not used.
+// The value of Uniforms is float32, float64 (float), [2]float32 (vec2), [3]float32 (vec3), [4]float32 (vec4),
+// [3][3]float32 (mat3), [4][4]float32 (mat4) or *Image (sampler2D).
+// The matrices are indexed by rows and then columns.
+//
+// The source image is sampled with its edges clamped: texture coordinates outside the image
+// (e.g. from a shader or a linear filter at the edges) get the nearest edge pixels and the image is never repeated.
+//
+// Drawing is deferred until the result is needed (e.g. At or the end of the frame),
+// and consecutive calls with the same images and the same ColorM are merged into one draw call.
+// It would still be better if you could call this method fewer times.
+func (i *Image) DrawImage(image *Image, options *DrawImageOptions) error {
+	if err := i.checkTarget(); err != nil {
+		return err
+	}
+	if image.isDisposed() {
+		return errImageDisposed
+	}
+	if i.texture == image.texture {
 		return errors.New("Image.DrawImage: image should be different from the receiver")
 	}
 	i.pixels = nil
 	if options == nil {
 		options = &DrawImageOptions{}
 	}
-	parts := options.ImageParts
+	parts := options.FloatImageParts
 	if parts == nil {
-		// Check options.Parts for backward-compatibility.
-		dparts := options.Parts
-		if dparts != nil {
-			parts = imageParts(dparts)
-		} else {
-			w, h := image.Size()
-			parts = &wholeImage{w, h}
+		intParts := options.ImageParts
+		if intParts == nil {
+			// Check options.Parts for backward-compatibility.
+			dparts := options.Parts
+			if dparts != nil {
+				intParts = imageParts(dparts)
+			} else {
+				w, h := image.Size()
+				intParts = &wholeImage{w, h}
+			}
 		}
+		parts = &floatImageParts{intParts}
 	}
-	w, h := image.Size()
+	w, h := image.texture.InternalSize()
 	quads := &textureQuads{parts: parts, width: w, height: h}
+	if image.parent != nil {
+		quads.offsetX, quads.offsetY = image.region.Min.X, image.region.Min.Y
+	}
+	if err := checkCompositeMode(options.CompositeMode); err != nil {
+		return err
+	}
+	mode := opengl.CompositeMode(options.CompositeMode)
+	if mask := options.Mask; mask != nil {
+		if mask.isDisposed() {
+			return errImageDisposed
+		}
+		if mask.texture == i.texture {
+			return errors.New("Image.DrawImage: the mask should be different from the receiver")
+		}
+		if options.Shader != nil {
+			return errors.New("Image.DrawImage: Mask and Shader can't be used at the same time")
+		}
+		r := mask.Bounds()
+		if mask.parent != nil {
+			r = mask.region
+		}
+		i.framebuffer.EnqueueDrawTextureWithMask(image.texture, quads, &options.GeoM, &options.ColorM, mask.texture, r, mode)
+		return nil
+	}
+	if options.Shader != nil {
+		if options.Shader.shader == nil {
+			return errors.New("ebiten: the shader is already disposed")
+		}
+		floats, textures, err := uniforms(i, options.Uniforms)
+		if err != nil {
+			return err
+		}
+		i.framebuffer.EnqueueDrawTextureWithShader(image.texture, quads, &options.GeoM, options.Shader.shader, floats, textures, mode)
+		return nil
+	}
+	i.framebuffer.EnqueueDrawTexture(image.texture, quads, &options.GeoM, &options.ColorM, mode)
+	return nil
+}
+
+// A Vertex represents a vertex passed to DrawTriangles.
+type Vertex struct {
+	// DstX and DstY represent the position on the destination image.
+	DstX float32
+	DstY float32
+
+	// SrcX and SrcY represent the position on the source image in pixels.
+	SrcX float32
+	SrcY float32
+
+	// ColorR, ColorG, ColorB and ColorA represent the color scale [0-1] multiplied to the source color.
+	// These are not alpha-premultiplied: (1, 1, 1, 1) doesn't change the color.
+	ColorR float32
+	ColorG float32
+	ColorB float32
+	ColorA float32
+}
+
+// DrawTriangles draws triangles with the source image on the receiver image.
+//
+// indices is a list of the indices of vertices. Every 3 indices represent a triangle.
+// The source colors are converted with options.ColorM first and then multiplied by the vertices' colors,
+// interpolated in the triangles.
+//
+// The number of vertices must be equal to or less than 43688,
+// and the number of indices must be equal to or less than 65536 and a multiple of 3.
+func (i *Image) DrawTriangles(vertices []Vertex, indices []uint16, image *Image, options *DrawTrianglesOptions) (err error) {
+	if err := i.checkTarget(); err != nil {
+		return err
+	}
+	if image.isDisposed() {
+		return errImageDisposed
+	}
+	if i.texture == image.texture {
+		return errors.New("Image.DrawTriangles: image should be different from the receiver")
+	}
+	if graphics.TrianglesVerticesMaxNum < len(vertices) {
+		return errors.New(fmt.Sprintf("ebiten: len(vertices) must be equal to or less than %d", graphics.TrianglesVerticesMaxNum))
+	}
+	if graphics.TrianglesIndicesMaxNum < len(indices) {
+		return errors.New(fmt.Sprintf("ebiten: len(indices) must be equal to or less than %d", graphics.TrianglesIndicesMaxNum))
+	}
+	if len(indices)%3 != 0 {
+		return errors.New("ebiten: len(indices) must be a multiple of 3")
+	}
+	for _, idx := range indices {
+		if len(vertices) <= int(idx) {
+			return errors.New(fmt.Sprintf("ebiten: index %d is out of range", idx))
+		}
+	}
+	i.pixels = nil
+	if options == nil {
+		options = &DrawTrianglesOptions{}
+	}
+	w, h := image.texture.InternalSize()
+	ox, oy := float32(0), float32(0)
+	if image.parent != nil {
+		ox, oy = float32(image.region.Min.X), float32(image.region.Min.Y)
+	}
+	vs := make([]float32, 0, 8*len(vertices))
+	for _, v := range vertices {
+		a := v.ColorA
+		vs = append(vs,
+			v.DstX, v.DstY,
+			(v.SrcX+ox)/float32(w), (v.SrcY+oy)/float32(h),
+			v.ColorR*a, v.ColorG*a, v.ColorB*a, a)
+	}
+	if err := checkCompositeMode(options.CompositeMode); err != nil {
+		return err
+	}
+	mode := opengl.CompositeMode(options.CompositeMode)
 	ui.Use(func(c *opengl.Context) {
-		err = i.framebuffer.DrawTexture(c, image.texture, quads, &options.GeoM, &options.ColorM)
+		err = i.framebuffer.DrawTriangles(c, image.texture, vs, indices, &options.ColorM, mode)
 	})
 	return
 }
@@ -101,9 +378,34 @@ func (i *Image) DrawLine(x0, y0, x1, y1 int, clr color.Color) error {
 }
 
 // DrawLines draws lines.
-func (i *Image) DrawLines(lines Lines) (err error) {
+func (i *Image) DrawLines(lines Lines) error {
+	return i.DrawLinesWithOptions(lines, nil)
+}
+
+// DrawLinesWithOptions draws lines with the options.
+func (i *Image) DrawLinesWithOptions(lines Lines, options *DrawLinesOptions) error {
+	return i.drawLines(&intLines{lines}, options)
+}
+
+// DrawFloatLines draws lines in floating-point coordinates with the options.
+func (i *Image) DrawFloatLines(lines FloatLines, options *DrawLinesOptions) error {
+	return i.drawLines(&floatLines{lines}, options)
+}
+
+func (i *Image) drawLines(lines graphics.Lines, options *DrawLinesOptions) (err error) {
+	if err := i.checkTarget(); err != nil {
+		return err
+	}
+	i.pixels = nil
+	if options == nil {
+		options = &DrawLinesOptions{}
+	}
+	if err := checkCompositeMode(options.CompositeMode); err != nil {
+		return err
+	}
+	mode := opengl.CompositeMode(options.CompositeMode)
 	ui.Use(func(c *opengl.Context) {
-		err = i.framebuffer.DrawLines(c, lines)
+		err = i.framebuffer.DrawLines(c, lines, mode)
 	})
 	return
 }
@@ -124,9 +426,34 @@ func (i *Image) DrawFilledRect(x, y, width, height int, clr color.Color) error {
 }
 
 // DrawFilledRects draws filled rectangles on the image.
-func (i *Image) DrawFilledRects(rects Rects) (err error) {
+func (i *Image) DrawFilledRects(rects Rects) error {
+	return i.DrawFilledRectsWithOptions(rects, nil)
+}
+
+// DrawFilledRectsWithOptions draws filled rectangles on the image with the options.
+func (i *Image) DrawFilledRectsWithOptions(rects Rects, options *DrawFilledRectsOptions) error {
+	return i.drawFilledRects(&intRects{rects}, options)
+}
+
+// DrawFloatFilledRects draws filled rectangles in floating-point coordinates on the image with the options.
+func (i *Image) DrawFloatFilledRects(rects FloatRects, options *DrawFilledRectsOptions) error {
+	return i.drawFilledRects(&floatRects{rects}, options)
+}
+
+func (i *Image) drawFilledRects(rects graphics.Rects, options *DrawFilledRectsOptions) (err error) {
+	if err := i.checkTarget(); err != nil {
+		return err
+	}
+	i.pixels = nil
+	if options == nil {
+		options = &DrawFilledRectsOptions{}
+	}
+	if err := checkCompositeMode(options.CompositeMode); err != nil {
+		return err
+	}
+	mode := opengl.CompositeMode(options.CompositeMode)
 	ui.Use(func(c *opengl.Context) {
-		err = i.framebuffer.DrawFilledRects(c, rects)
+		err = i.framebuffer.DrawFilledRects(c, rects, mode)
 	})
 	return
 }
@@ -146,6 +473,15 @@ func (i *Image) ColorModel() color.Model {
 //
 // This method loads pixels from VRAM to system memory if necessary.
 func (i *Image) At(x, y int) color.Color {
+	if i.isDisposed() {
+		return color.RGBA{}
+	}
+	if i.parent != nil {
+		if !image.Pt(x, y).In(image.Rect(0, 0, i.region.Dx(), i.region.Dy())) {
+			return color.RGBA{}
+		}
+		return i.parent.At(x+i.region.Min.X, y+i.region.Min.Y)
+	}
 	if i.pixels == nil {
 		ui.Use(func(c *opengl.Context) {
 			var err error
@@ -156,7 +492,6 @@ func (i *Image) At(x, y int) color.Color {
 		})
 	}
 	w, _ := i.Size()
-	w = internal.NextPowerOf2Int(w)
 	idx := 4*x + 4*y*w
 	r, g, b, a := i.pixels[idx], i.pixels[idx+1], i.pixels[idx+2], i.pixels[idx+3]
 	return color.RGBA{r, g, b, a}
@@ -164,10 +499,37 @@ func (i *Image) At(x, y int) color.Color {
 
 // A DrawImageOptions represents options to render an image on an image.
 type DrawImageOptions struct {
-	ImageParts ImageParts
-	GeoM       GeoM
-	ColorM     ColorM
+	ImageParts    ImageParts
+	GeoM          GeoM
+	ColorM        ColorM
+	CompositeMode CompositeMode
+	Shader        *Shader
+	Uniforms      map[string]interface{}
+
+	// Mask is an image aligned with the destination image (the receiver of DrawImage).
+	// The drawn colors are multiplied by the alpha values of Mask at the same positions,
+	// and nothing is drawn outside Mask. GeoM and ColorM don't affect Mask.
+	Mask *Image
+
+	// FloatImageParts is used instead of ImageParts when specified.
+	FloatImageParts FloatImageParts
 
 	// Deprecated (as of 1.1.0-alpha): Use ImageParts instead.
 	Parts []ImagePart
 }
+
+// A DrawTrianglesOptions represents options to render triangles on an image.
+type DrawTrianglesOptions struct {
+	ColorM        ColorM
+	CompositeMode CompositeMode
+}
+
+// A DrawLinesOptions represents options to render lines on an image.
+type DrawLinesOptions struct {
+	CompositeMode CompositeMode
+}
+
+// A DrawFilledRectsOptions represents options to render filled rectangles on an image.
+type DrawFilledRectsOptions struct {
+	CompositeMode CompositeMode
+}
@@ -174,4 +174,626 @@ func TestImageDotByDotInversion(t *testing.T) {
 	}
 }
 
+func TestImageSubImage(t *testing.T) {
+	img0, _, err := openImage("testdata/ebiten.png")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	sub := img0.SubImage(image.Rect(8, 4, 24, 12)).SubImage(image.Rect(2, 2, 100, 6))
+	if got, want := sub.Bounds(), image.Rect(0, 0, 14, 4); got != want {
+		t.Errorf("sub.Bounds(): got %v; want %v", got, want)
+	}
+	for j := 0; j < 4; j++ {
+		for i := 0; i < 14; i++ {
+			if got, want := sub.At(i, j), img0.At(i+10, j+6); got != want {
+				t.Errorf("sub.At(%d, %d): got %v; want %v", i, j, got, want)
+			}
+		}
+	}
+
+	img1, err := NewImage(16, 16, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	op := &DrawImageOptions{}
+	op.GeoM.Translate(1, 2)
+	if err := img1.DrawImage(sub, op); err != nil {
+		t.Fatal(err)
+		return
+	}
+	for j := 0; j < 16; j++ {
+		for i := 0; i < 16; i++ {
+			want := color.Color(color.RGBA{})
+			if 1 <= i && i < 15 && 2 <= j && j < 6 {
+				want = sub.At(i-1, j-2)
+			}
+			if got := img1.At(i, j); got != want {
+				t.Errorf("img1.At(%d, %d): got %v; want %v", i, j, got, want)
+			}
+		}
+	}
+	if err := img0.DrawImage(sub, nil); err == nil {
+		t.Errorf("img0.DrawImage(sub, nil) doesn't return error; an error should be returned")
+	}
+}
+
 // TODO: Add more tests (e.g. DrawImage with color matrix)
+
+func TestImageDeferredDrawing(t *testing.T) {
+	src, err := NewImage(4, 4, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if err := src.Fill(color.White); err != nil {
+		t.Fatal(err)
+		return
+	}
+	dst, err := NewImage(16, 16, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	// These calls are merged into one command (see TestEnqueueDrawTextureMerge in internal/graphics).
+	for j := 0; j < 16; j += 8 {
+		for i := 0; i < 16; i += 4 {
+			op := &DrawImageOptions{}
+			op.GeoM.Translate(float64(i), float64(j))
+			if err := dst.DrawImage(src, op); err != nil {
+				t.Fatal(err)
+				return
+			}
+		}
+	}
+	// Fill must not be reordered before the deferred commands.
+	if err := src.Fill(color.Black); err != nil {
+		t.Fatal(err)
+		return
+	}
+	for j := 0; j < 16; j++ {
+		for i := 0; i < 16; i++ {
+			got := dst.At(i, j)
+			want := color.RGBA{}
+			if j%8 < 4 {
+				want = color.RGBA{0xff, 0xff, 0xff, 0xff}
+			}
+			if got != want {
+				t.Errorf("dst.At(%d, %d): got %#v; want %#v", i, j, got, want)
+			}
+		}
+	}
+}
+
+func TestImageDispose(t *testing.T) {
+	img, _, err := openImage("testdata/ebiten.png")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	sub := img.SubImage(image.Rect(0, 0, 4, 4))
+	dst, err := NewImage(16, 16, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if err := img.Dispose(); err != nil {
+		t.Fatal(err)
+		return
+	}
+	if err := img.Fill(color.White); err == nil {
+		t.Errorf("img.Fill after disposing must return an error")
+	}
+	if err := dst.DrawImage(img, nil); err == nil {
+		t.Errorf("dst.DrawImage(img) after disposing must return an error")
+	}
+	if err := dst.DrawImage(sub, nil); err == nil {
+		t.Errorf("dst.DrawImage(sub) after disposing the parent must return an error")
+	}
+	if err := img.Dispose(); err == nil {
+		t.Errorf("img.Dispose after disposing must return an error")
+	}
+	if got, want := img.At(0, 0), (color.RGBA{}); got != want {
+		t.Errorf("img.At(0, 0): got %#v; want %#v", got, want)
+	}
+}
+
+func TestImageReplacePixels(t *testing.T) {
+	img, err := NewImage(16, 16, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	pix := make([]uint8, 4*16*16)
+	for i := range pix {
+		pix[i] = 0x80
+	}
+	if err := img.ReplacePixels(pix); err != nil {
+		t.Fatal(err)
+		return
+	}
+	pix = make([]uint8, 4*2*3)
+	for i := range pix {
+		pix[i] = 0xff
+	}
+	if err := img.ReplacePixelsRect(pix, image.Rect(4, 5, 6, 8)); err != nil {
+		t.Fatal(err)
+		return
+	}
+	for j := 0; j < 16; j++ {
+		for i := 0; i < 16; i++ {
+			got := img.At(i, j)
+			want := color.RGBA{0x80, 0x80, 0x80, 0x80}
+			if image.Pt(i, j).In(image.Rect(4, 5, 6, 8)) {
+				want = color.RGBA{0xff, 0xff, 0xff, 0xff}
+			}
+			if got != want {
+				t.Errorf("img.At(%d, %d): got %#v; want %#v", i, j, got, want)
+			}
+		}
+	}
+	if err := img.ReplacePixels(pix); err == nil {
+		t.Errorf("ReplacePixels with a wrong length must return an error")
+	}
+	if err := img.ReplacePixelsRect(pix, image.Rect(15, 15, 17, 18)); err == nil {
+		t.Errorf("ReplacePixelsRect with an out-of-range rectangle must return an error")
+	}
+}
+
+func TestImageSmallAndNPOTSizes(t *testing.T) {
+	src, err := NewImage(1, 1, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if err := src.Fill(color.White); err != nil {
+		t.Fatal(err)
+		return
+	}
+	dst, err := NewImage(5, 3, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if got, want := dst.Bounds().Size(), image.Pt(5, 3); got != want {
+		t.Errorf("dst size: got %v; want %v", got, want)
+	}
+	op := &DrawImageOptions{}
+	op.GeoM.Scale(5, 3)
+	if err := dst.DrawImage(src, op); err != nil {
+		t.Fatal(err)
+		return
+	}
+	for j := 0; j < 3; j++ {
+		for i := 0; i < 5; i++ {
+			got := dst.At(i, j)
+			want := color.RGBA{0xff, 0xff, 0xff, 0xff}
+			if got != want {
+				t.Errorf("dst.At(%d, %d): got %#v; want %#v", i, j, got, want)
+			}
+		}
+	}
+}
+
+type testRect struct {
+	x, y, width, height int
+	color               color.Color
+}
+
+func (r *testRect) Len() int {
+	return 1
+}
+
+func (r *testRect) Rect(i int) (x, y, width, height int) {
+	return r.x, r.y, r.width, r.height
+}
+
+func (r *testRect) Color(i int) color.Color {
+	return r.color
+}
+
+func TestImageCompositeMode(t *testing.T) {
+	src, err := NewImage(4, 4, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	dst, err := NewImage(4, 4, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	// Alpha-premultiplied colors
+	s := color.RGBA{0x40, 0x20, 0x10, 0x80}
+	d := color.RGBA{0x20, 0x80, 0xc0, 0xff}
+	blend := func(sf, df func(sc, dc, sa uint8) float64) color.RGBA {
+		f := func(sc, dc uint8) uint8 {
+			v := float64(sc)*sf(sc, dc, s.A)/0xff + float64(dc)*df(sc, dc, s.A)/0xff
+			return uint8(math.Min(math.Floor(v+0.5), 0xff))
+		}
+		return color.RGBA{f(s.R, d.R), f(s.G, d.G), f(s.B, d.B), f(s.A, d.A)}
+	}
+	zero := func(sc, dc, sa uint8) float64 { return 0 }
+	one := func(sc, dc, sa uint8) float64 { return 0xff }
+	oneMinusSrcAlpha := func(sc, dc, sa uint8) float64 { return float64(0xff - sa) }
+	dstColor := func(sc, dc, sa uint8) float64 { return float64(dc) }
+	oneMinusSrcColor := func(sc, dc, sa uint8) float64 { return float64(0xff - sc) }
+	cases := []struct {
+		mode CompositeMode
+		want color.RGBA
+	}{
+		{CompositeModeSourceOver, blend(one, oneMinusSrcAlpha)},
+		{CompositeModeCopy, blend(one, zero)},
+		{CompositeModeDestinationOut, blend(zero, oneMinusSrcAlpha)},
+		{CompositeModeLighter, blend(one, one)},
+		{CompositeModeMultiply, blend(dstColor, oneMinusSrcAlpha)},
+		{CompositeModeScreen, blend(one, oneMinusSrcColor)},
+	}
+	for _, c := range cases {
+		if err := src.Fill(s); err != nil {
+			t.Fatal(err)
+			return
+		}
+		if err := dst.Fill(d); err != nil {
+			t.Fatal(err)
+			return
+		}
+		op := &DrawImageOptions{CompositeMode: c.mode}
+		if err := dst.DrawImage(src, op); err != nil {
+			t.Fatal(err)
+			return
+		}
+		got := dst.At(0, 0).(color.RGBA)
+		if diff(got.R, c.want.R) > 1 || diff(got.G, c.want.G) > 1 || diff(got.B, c.want.B) > 1 || diff(got.A, c.want.A) > 1 {
+			t.Errorf("mode %d: got %#v; want %#v", c.mode, got, c.want)
+		}
+
+		if err := dst.Fill(d); err != nil {
+			t.Fatal(err)
+			return
+		}
+		if err := dst.DrawFilledRectsWithOptions(&testRect{0, 0, 4, 4, s}, &DrawFilledRectsOptions{CompositeMode: c.mode}); err != nil {
+			t.Fatal(err)
+			return
+		}
+		got = dst.At(0, 0).(color.RGBA)
+		if diff(got.R, c.want.R) > 1 || diff(got.G, c.want.G) > 1 || diff(got.B, c.want.B) > 1 || diff(got.A, c.want.A) > 1 {
+			t.Errorf("mode %d (filled rects): got %#v; want %#v", c.mode, got, c.want)
+		}
+	}
+}
+
+type testLine struct {
+	x0, y0, x1, y1 int
+	color          color.Color
+}
+
+func (l *testLine) Len() int {
+	return 1
+}
+
+func (l *testLine) Points(i int) (x0, y0, x1, y1 int) {
+	return l.x0, l.y0, l.x1, l.y1
+}
+
+func (l *testLine) Color(i int) color.Color {
+	return l.color
+}
+
+func TestImageInvalidCompositeMode(t *testing.T) {
+	src, err := NewImage(4, 4, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	dst, err := NewImage(4, 4, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	vertices := []Vertex{
+		{0, 0, 0, 0, 1, 1, 1, 1},
+		{4, 0, 4, 0, 1, 1, 1, 1},
+		{0, 4, 0, 4, 1, 1, 1, 1},
+	}
+	for _, mode := range []CompositeMode{-1, CompositeModeScreen + 1} {
+		if err := dst.DrawImage(src, &DrawImageOptions{CompositeMode: mode}); err == nil {
+			t.Errorf("DrawImage with mode %d must return an error", mode)
+		}
+		if err := dst.DrawLinesWithOptions(&testLine{0, 0, 4, 4, color.White}, &DrawLinesOptions{CompositeMode: mode}); err == nil {
+			t.Errorf("DrawLinesWithOptions with mode %d must return an error", mode)
+		}
+		if err := dst.DrawFilledRectsWithOptions(&testRect{0, 0, 4, 4, color.White}, &DrawFilledRectsOptions{CompositeMode: mode}); err == nil {
+			t.Errorf("DrawFilledRectsWithOptions with mode %d must return an error", mode)
+		}
+		if err := dst.DrawTriangles(vertices, []uint16{0, 1, 2}, src, &DrawTrianglesOptions{CompositeMode: mode}); err == nil {
+			t.Errorf("DrawTriangles with mode %d must return an error", mode)
+		}
+	}
+	// Nothing is drawn and the image is still usable.
+	if got, want := dst.At(0, 0), (color.RGBA{}); got != want {
+		t.Errorf("dst.At(0, 0): got %#v; want %#v", got, want)
+	}
+}
+
+func TestImageDrawTriangles(t *testing.T) {
+	src, err := NewImage(4, 4, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if err := src.Fill(color.White); err != nil {
+		t.Fatal(err)
+		return
+	}
+	dst, err := NewImage(8, 8, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	vertices := []Vertex{
+		{0, 0, 0, 0, 1, 0, 0, 1},
+		{8, 0, 4, 0, 1, 0, 0, 1},
+		{0, 8, 0, 4, 1, 0, 0, 1},
+	}
+	op := &DrawTrianglesOptions{}
+	op.ColorM.Scale(1, 1, 1, 0.5)
+	if err := dst.DrawTriangles(vertices, []uint16{0, 1, 2}, src, op); err != nil {
+		t.Fatal(err)
+		return
+	}
+	for j := 0; j < 8; j++ {
+		for i := 0; i < 8; i++ {
+			// Skip the pixels whose centers are exactly on the edge.
+			if i+j == 7 {
+				continue
+			}
+			got := dst.At(i, j).(color.RGBA)
+			want := color.RGBA{}
+			if i+j < 7 {
+				want = color.RGBA{0x80, 0, 0, 0x80}
+			}
+			if diff(got.R, want.R) > 1 || got.G != want.G || got.B != want.B || diff(got.A, want.A) > 1 {
+				t.Errorf("dst.At(%d, %d): got %#v; want %#v", i, j, got, want)
+			}
+		}
+	}
+	if err := dst.DrawTriangles(vertices, []uint16{0, 1, 3}, src, nil); err == nil {
+		t.Errorf("DrawTriangles with an out-of-range index must return an error")
+	}
+	if err := dst.DrawTriangles(vertices, []uint16{0, 1}, src, nil); err == nil {
+		t.Errorf("DrawTriangles with len(indices) not a multiple of 3 must return an error")
+	}
+}
+
+type testFloatPart struct {
+	dst [4]float64
+	src [4]float64
+}
+
+func (p *testFloatPart) Len() int {
+	return 1
+}
+
+func (p *testFloatPart) Dst(i int) (x0, y0, x1, y1 float64) {
+	return p.dst[0], p.dst[1], p.dst[2], p.dst[3]
+}
+
+func (p *testFloatPart) Src(i int) (x0, y0, x1, y1 float64) {
+	return p.src[0], p.src[1], p.src[2], p.src[3]
+}
+
+type testFloatRect struct {
+	x, y, width, height float64
+	color               color.Color
+}
+
+func (r *testFloatRect) Len() int {
+	return 1
+}
+
+func (r *testFloatRect) Rect(i int) (x, y, width, height float64) {
+	return r.x, r.y, r.width, r.height
+}
+
+func (r *testFloatRect) Color(i int) color.Color {
+	return r.color
+}
+
+func TestImageSubPixelPositions(t *testing.T) {
+	src, err := NewImage(2, 2, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if err := src.Fill(color.White); err != nil {
+		t.Fatal(err)
+		return
+	}
+	dst0, err := NewImage(4, 4, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	dst1, err := NewImage(4, 4, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	// The pixel centers in [0.6, 2.6) are 1.5 and 2.5.
+	op := &DrawImageOptions{
+		FloatImageParts: &testFloatPart{
+			dst: [4]float64{0.6, 0.6, 2.6, 2.6},
+			src: [4]float64{0, 0, 2, 2},
+		},
+	}
+	if err := dst0.DrawImage(src, op); err != nil {
+		t.Fatal(err)
+		return
+	}
+	if err := dst1.DrawFloatFilledRects(&testFloatRect{0.6, 0.6, 2, 2, color.White}, nil); err != nil {
+		t.Fatal(err)
+		return
+	}
+	for _, dst := range []*Image{dst0, dst1} {
+		for j := 0; j < 4; j++ {
+			for i := 0; i < 4; i++ {
+				got := dst.At(i, j)
+				want := color.RGBA{}
+				if 1 <= i && i < 3 && 1 <= j && j < 3 {
+					want = color.RGBA{0xff, 0xff, 0xff, 0xff}
+				}
+				if got != want {
+					t.Errorf("dst.At(%d, %d): got %#v; want %#v", i, j, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestImageClip(t *testing.T) {
+	src, err := NewImage(8, 8, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if err := src.Fill(color.White); err != nil {
+		t.Fatal(err)
+		return
+	}
+	dst, err := NewImage(8, 8, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	white := color.RGBA{0xff, 0xff, 0xff, 0xff}
+	if err := dst.SetClip(image.Rect(0, 0, 4, 4)); err != nil {
+		t.Fatal(err)
+		return
+	}
+	if err := dst.Fill(red); err != nil {
+		t.Fatal(err)
+		return
+	}
+	// Drawing is deferred, but each command keeps the clip rectangle at the time.
+	if err := dst.SetClip(image.Rect(2, 2, 6, 6)); err != nil {
+		t.Fatal(err)
+		return
+	}
+	if err := dst.DrawImage(src, nil); err != nil {
+		t.Fatal(err)
+		return
+	}
+	if err := dst.SetClip(image.Rect(6, 6, 10, 10)); err != nil {
+		t.Fatal(err)
+		return
+	}
+	if err := dst.DrawImage(src, nil); err != nil {
+		t.Fatal(err)
+		return
+	}
+	for j := 0; j < 8; j++ {
+		for i := 0; i < 8; i++ {
+			got := dst.At(i, j)
+			want := color.RGBA{}
+			p := image.Pt(i, j)
+			switch {
+			case p.In(image.Rect(2, 2, 6, 6)) || p.In(image.Rect(6, 6, 8, 8)):
+				want = white
+			case p.In(image.Rect(0, 0, 4, 4)):
+				want = red
+			}
+			if got != want {
+				t.Errorf("dst.At(%d, %d): got %#v; want %#v", i, j, got, want)
+			}
+		}
+	}
+
+	if err := dst.ResetClip(); err != nil {
+		t.Fatal(err)
+		return
+	}
+	if err := dst.Clear(); err != nil {
+		t.Fatal(err)
+		return
+	}
+	for j := 0; j < 8; j++ {
+		for i := 0; i < 8; i++ {
+			got := dst.At(i, j)
+			want := color.RGBA{}
+			if got != want {
+				t.Errorf("dst.At(%d, %d): got %#v; want %#v", i, j, got, want)
+			}
+		}
+	}
+}
+
+func TestImageMask(t *testing.T) {
+	src, err := NewImage(4, 4, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if err := src.Fill(color.White); err != nil {
+		t.Fatal(err)
+		return
+	}
+	// The mask is opaque at the left half, translucent at (2, y) and transparent at (3, y).
+	pix := make([]uint8, 4*8*8)
+	for j := 0; j < 8; j++ {
+		for i := 0; i < 8; i++ {
+			a := uint8(0)
+			switch {
+			case i < 2:
+				a = 0xff
+			case i == 2:
+				a = 0x80
+			}
+			copy(pix[4*(i+j*8):], []uint8{a, a, a, a})
+		}
+	}
+	mask, err := NewImage(8, 8, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if err := mask.ReplacePixels(pix); err != nil {
+		t.Fatal(err)
+		return
+	}
+	dst, err := NewImage(8, 8, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	// The mask is not affected by GeoM: the source is moved to (1, 1) - (5, 5).
+	op := &DrawImageOptions{
+		Mask: mask.SubImage(image.Rect(0, 0, 4, 4)),
+	}
+	op.GeoM.Translate(1, 1)
+	op.ColorM.Scale(1, 0, 0, 1)
+	if err := dst.DrawImage(src, op); err != nil {
+		t.Fatal(err)
+		return
+	}
+	for j := 0; j < 8; j++ {
+		for i := 0; i < 8; i++ {
+			got := dst.At(i, j)
+			want := color.RGBA{}
+			// Nothing is drawn outside the sub image of the mask.
+			if 1 <= i && 1 <= j && j < 4 {
+				switch i {
+				case 1:
+					want = color.RGBA{0xff, 0, 0, 0xff}
+				case 2:
+					want = color.RGBA{0x80, 0, 0, 0x80}
+				}
+			}
+			if got != want {
+				t.Errorf("dst.At(%d, %d): got %#v; want %#v", i, j, got, want)
+			}
+		}
+	}
+}
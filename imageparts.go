@@ -15,9 +15,7 @@
 package ebiten
 
 import (
-	"github.com/hajimehoshi/ebiten/internal"
 	"image"
-	"math"
 )
 
 // Deprecated (as of 1.1.0-alpha): Use ImageParts instead.
@@ -67,30 +65,56 @@ func (w *wholeImage) Src(i int) (x0, y0, x1, y1 int) {
 	return 0, 0, w.width, w.height
 }
 
-func u(x int, width int) int {
-	return math.MaxInt16 * x / internal.NextPowerOf2Int(width)
+// A FloatImageParts represents the parts of the destination image and the parts of the source image
+// in floating-point coordinates. This enables sub-pixel positioning.
+type FloatImageParts interface {
+	Len() int
+	Dst(i int) (x0, y0, x1, y1 float64)
+	Src(i int) (x0, y0, x1, y1 float64)
+}
+
+// floatImageParts adapts ImageParts to FloatImageParts.
+type floatImageParts struct {
+	parts ImageParts
+}
+
+func (p *floatImageParts) Len() int {
+	return p.parts.Len()
 }
 
-func v(y int, height int) int {
-	return math.MaxInt16 * y / internal.NextPowerOf2Int(height)
+func (p *floatImageParts) Dst(i int) (x0, y0, x1, y1 float64) {
+	ix0, iy0, ix1, iy1 := p.parts.Dst(i)
+	return float64(ix0), float64(iy0), float64(ix1), float64(iy1)
 }
 
+func (p *floatImageParts) Src(i int) (x0, y0, x1, y1 float64) {
+	ix0, iy0, ix1, iy1 := p.parts.Src(i)
+	return float64(ix0), float64(iy0), float64(ix1), float64(iy1)
+}
+
+// textureQuads converts FloatImageParts into quads. width and height are the internal size of the texture.
 type textureQuads struct {
-	parts  ImageParts
-	width  int
-	height int
+	parts   FloatImageParts
+	width   int
+	height  int
+	offsetX int
+	offsetY int
 }
 
 func (t *textureQuads) Len() int {
 	return t.parts.Len()
 }
 
-func (t *textureQuads) Vertex(i int) (x0, y0, x1, y1 int) {
-	return t.parts.Dst(i)
+func (t *textureQuads) Vertex(i int) (x0, y0, x1, y1 float32) {
+	fx0, fy0, fx1, fy1 := t.parts.Dst(i)
+	return float32(fx0), float32(fy0), float32(fx1), float32(fy1)
 }
 
-func (t *textureQuads) Texture(i int) (u0, v0, u1, v1 int) {
+// Texture returns the normalized texture coordinates.
+func (t *textureQuads) Texture(i int) (u0, v0, u1, v1 float32) {
 	x0, y0, x1, y1 := t.parts.Src(i)
-	w, h := t.width, t.height
-	return u(x0, w), v(y0, h), u(x1, w), v(y1, h)
+	x0, x1 = x0+float64(t.offsetX), x1+float64(t.offsetX)
+	y0, y1 = y0+float64(t.offsetY), y1+float64(t.offsetY)
+	w, h := float64(t.width), float64(t.height)
+	return float32(x0 / w), float32(y0 / h), float32(x1 / w), float32(y1 / h)
 }
@@ -0,0 +1,241 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphics
+
+import (
+	"github.com/hajimehoshi/ebiten/internal/graphics/internal/shader"
+	"github.com/hajimehoshi/ebiten/internal/opengl"
+	"image"
+	"sync"
+)
+
+// Drawing textures is deferred: DrawTexture commands are recorded into the queue,
+// and consecutive commands with the same target, source, matrices, clip and composite mode are merged into one draw call.
+// The queue is flushed before any other operation on framebuffers or textures.
+
+type quad struct {
+	x0, y0, x1, y1 float32
+	u0, v0, u1, v1 float32
+}
+
+type quads []quad
+
+func (q quads) Len() int {
+	return len(q)
+}
+
+func (q quads) Vertex(i int) (x0, y0, x1, y1 float32) {
+	return q[i].x0, q[i].y0, q[i].x1, q[i].y1
+}
+
+func (q quads) Texture(i int) (u0, v0, u1, v1 float32) {
+	return q[i].u0, q[i].v0, q[i].u1, q[i].v1
+}
+
+type geoM [2][3]float64
+
+func (g *geoM) Element(i, j int) float64 {
+	return g[i][j]
+}
+
+type colorM [4][5]float64
+
+func (c *colorM) Element(i, j int) float64 {
+	return c[i][j]
+}
+
+type drawTextureCommand struct {
+	framebuffer *Framebuffer
+	texture     *Texture
+	quads       quads
+	geo         geoM
+	color       colorM
+	shader      *userShader
+	mask        *textureMask
+	clip        image.Rectangle
+	mode        opengl.CompositeMode
+}
+
+// textureMask is a texture whose alpha values multiply the drawn colors.
+// region is the part of the texture aligned with the destination.
+type textureMask struct {
+	texture *Texture
+	region  image.Rectangle
+}
+
+func (m *textureMask) native() *shader.Mask {
+	if m == nil {
+		return nil
+	}
+	w, h := m.texture.InternalSize()
+	return &shader.Mask{
+		Texture: m.texture.native,
+		Region:  m.region,
+		Width:   w,
+		Height:  h,
+	}
+}
+
+func sameMasks(m0, m1 *textureMask) bool {
+	if m0 == nil || m1 == nil {
+		return m0 == m1
+	}
+	return *m0 == *m1
+}
+
+// userShader is a user-defined shader with the values of its uniform variables.
+type userShader struct {
+	shader   *Shader
+	floats   map[string][]float32
+	textures map[string]*Texture
+}
+
+func (s *userShader) uniforms() *shader.Uniforms {
+	u := &shader.Uniforms{
+		Floats:   s.floats,
+		Textures: map[string]opengl.Texture{},
+	}
+	for name, t := range s.textures {
+		u.Textures[name] = t.native
+	}
+	return u
+}
+
+type disposeCommand struct {
+	framebuffer *Framebuffer
+	texture     *Texture
+}
+
+// commandQueue can be used from any goroutine: images can be drawn from multiple goroutines
+// and disposals can be appended from finalizers. The commands are guarded by m.
+type commandQueue struct {
+	commands  []*drawTextureCommand
+	disposals []*disposeCommand
+	m         sync.Mutex
+}
+
+var theCommandQueue = &commandQueue{}
+
+// isAxisAligned returns a boolean indicating whether geo consists of only scaling and translation.
+// Such a matrix keeps quads axis-aligned and can be applied to the vertices in advance.
+func isAxisAligned(geo Matrix) bool {
+	return geo.Element(0, 1) == 0 && geo.Element(1, 0) == 0
+}
+
+// enqueueDrawTexture records the command. Either clr or s is nil. mask can be nil.
+func (q *commandQueue) enqueueDrawTexture(f *Framebuffer, t *Texture, qs TextureQuads, geo, clr Matrix, s *userShader, mask *textureMask, mode opengl.CompositeMode) {
+	c := &drawTextureCommand{
+		framebuffer: f,
+		texture:     t,
+		quads:       make(quads, 0, qs.Len()),
+		shader:      s,
+		mask:        mask,
+		clip:        f.clip,
+		mode:        mode,
+	}
+	// An axis-aligned matrix is applied to the vertices here so that more commands can be merged.
+	sx, sy, tx, ty := 1.0, 1.0, 0.0, 0.0
+	if isAxisAligned(geo) {
+		sx, sy = geo.Element(0, 0), geo.Element(1, 1)
+		tx, ty = geo.Element(0, 2), geo.Element(1, 2)
+		c.geo = geoM{{1, 0, 0}, {0, 1, 0}}
+	} else {
+		for i := 0; i < 2; i++ {
+			for j := 0; j < 3; j++ {
+				c.geo[i][j] = geo.Element(i, j)
+			}
+		}
+	}
+	if clr != nil {
+		for i := 0; i < 4; i++ {
+			for j := 0; j < 5; j++ {
+				c.color[i][j] = clr.Element(i, j)
+			}
+		}
+	}
+	for i := 0; i < qs.Len(); i++ {
+		x0, y0, x1, y1 := qs.Vertex(i)
+		u0, v0, u1, v1 := qs.Texture(i)
+		c.quads = append(c.quads, quad{
+			float32(sx*float64(x0) + tx), float32(sy*float64(y0) + ty),
+			float32(sx*float64(x1) + tx), float32(sy*float64(y1) + ty),
+			u0, v0, u1, v1,
+		})
+	}
+
+	q.m.Lock()
+	defer q.m.Unlock()
+	// Commands with user-defined shaders are not merged since the uniform values can't be compared.
+	if 0 < len(q.commands) && s == nil {
+		last := q.commands[len(q.commands)-1]
+		if last.shader == nil && last.framebuffer == c.framebuffer && last.texture == c.texture &&
+			last.geo == c.geo && last.color == c.color && last.clip == c.clip && last.mode == c.mode && sameMasks(last.mask, c.mask) &&
+			len(last.quads)+len(c.quads) <= shader.QuadsMaxNum {
+			last.quads = append(last.quads, c.quads...)
+			return
+		}
+	}
+	q.commands = append(q.commands, c)
+}
+
+func (q *commandQueue) enqueueDispose(f *Framebuffer, t *Texture) {
+	q.m.Lock()
+	defer q.m.Unlock()
+	q.disposals = append(q.disposals, &disposeCommand{f, t})
+}
+
+func (q *commandQueue) flush(c *opengl.Context) error {
+	// The commands enqueued while flushing are executed at the next flush.
+	q.m.Lock()
+	commands := q.commands
+	disposals := q.disposals
+	q.commands = nil
+	q.disposals = nil
+	q.m.Unlock()
+	for _, cmd := range commands {
+		if cmd.shader != nil {
+			if err := cmd.framebuffer.drawTextureWithShader(c, cmd.texture, cmd.quads, &cmd.geo, cmd.shader, cmd.clip, cmd.mode); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := cmd.framebuffer.drawTexture(c, cmd.texture, cmd.quads, &cmd.geo, &cmd.color, cmd.mask, cmd.clip, cmd.mode); err != nil {
+			return err
+		}
+	}
+	// Disposals must come after the draw commands which might use the textures.
+	for _, d := range disposals {
+		if d.framebuffer != nil {
+			c.DeleteFramebuffer(d.framebuffer.native)
+		}
+		if d.texture != nil {
+			c.DeleteTexture(d.texture.native)
+		}
+	}
+	return nil
+}
+
+// EnqueueDispose records the command to delete the framebuffer and the texture (either can be nil).
+// They are deleted at the next FlushCommands.
+//
+// Unlike the other functions, this can be called from any goroutine.
+func EnqueueDispose(f *Framebuffer, t *Texture) {
+	theCommandQueue.enqueueDispose(f, t)
+}
+
+// FlushCommands executes all the deferred commands.
+func FlushCommands(c *opengl.Context) error {
+	return theCommandQueue.flush(c)
+}
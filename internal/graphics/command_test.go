@@ -0,0 +1,86 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphics
+
+import (
+	"github.com/hajimehoshi/ebiten/internal/opengl"
+	"sync"
+	"testing"
+)
+
+func translate(x, y float64) *geoM {
+	return &geoM{{1, 0, x}, {0, 1, y}}
+}
+
+var identityColorM = &colorM{
+	{1, 0, 0, 0, 0},
+	{0, 1, 0, 0, 0},
+	{0, 0, 1, 0, 0},
+	{0, 0, 0, 1, 0},
+}
+
+func TestEnqueueDrawTextureMerge(t *testing.T) {
+	q := &commandQueue{}
+	f := &Framebuffer{}
+	t0 := &Texture{}
+	t1 := &Texture{}
+	qs := quads{{0, 0, 4, 4, 0, 0, 1, 1}}
+	for i := 0; i < 4; i++ {
+		q.enqueueDrawTexture(f, t0, qs, translate(float64(i*4), 0), identityColorM, nil, nil, opengl.CompositeModeSourceOver)
+	}
+	if got, want := len(q.commands), 1; got != want {
+		t.Fatalf("len(q.commands): got %d, want: %d", got, want)
+	}
+	if got, want := len(q.commands[0].quads), 4; got != want {
+		t.Errorf("len(q.commands[0].quads): got %d, want: %d", got, want)
+	}
+	// The translation is applied to the vertices in advance.
+	if got, want := q.commands[0].quads[3], (quad{12, 0, 16, 4, 0, 0, 1, 1}); got != want {
+		t.Errorf("q.commands[0].quads[3]: got %v, want: %v", got, want)
+	}
+
+	// A different texture or composite mode breaks the merging.
+	q.enqueueDrawTexture(f, t1, qs, translate(0, 0), identityColorM, nil, nil, opengl.CompositeModeSourceOver)
+	q.enqueueDrawTexture(f, t1, qs, translate(0, 0), identityColorM, nil, nil, opengl.CompositeModeLighter)
+	if got, want := len(q.commands), 3; got != want {
+		t.Errorf("len(q.commands): got %d, want: %d", got, want)
+	}
+}
+
+func TestEnqueueDrawTextureConcurrently(t *testing.T) {
+	q := &commandQueue{}
+	f := &Framebuffer{}
+	tex := &Texture{}
+	qs := quads{{0, 0, 1, 1, 0, 0, 1, 1}}
+	const n = 8
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 16; j++ {
+				q.enqueueDrawTexture(f, tex, qs, translate(0, 0), identityColorM, nil, nil, opengl.CompositeModeSourceOver)
+			}
+		}()
+	}
+	wg.Wait()
+	num := 0
+	for _, c := range q.commands {
+		num += len(c.quads)
+	}
+	if got, want := num, n*16; got != want {
+		t.Errorf("the number of quads: got %d, want: %d", got, want)
+	}
+}
@@ -15,9 +15,9 @@
 package graphics
 
 import (
-	"github.com/hajimehoshi/ebiten/internal"
 	"github.com/hajimehoshi/ebiten/internal/graphics/internal/shader"
 	"github.com/hajimehoshi/ebiten/internal/opengl"
+	"image"
 	"image/color"
 )
 
@@ -36,17 +36,25 @@ func orthoProjectionMatrix(left, right, bottom, top int) *[4][4]float64 {
 }
 
 type Framebuffer struct {
-	native opengl.Framebuffer
-	width  int
-	height int
-	flipY  bool
+	native         opengl.Framebuffer
+	width          int
+	height         int
+	internalWidth  int
+	internalHeight int
+	flipY          bool
+
+	// clip is the rectangle where drawing is restricted to.
+	clip image.Rectangle
 }
 
 func NewZeroFramebuffer(c *opengl.Context, width, height int) (*Framebuffer, error) {
 	r := &Framebuffer{
-		width:  width,
-		height: height,
-		flipY:  true,
+		width:          width,
+		height:         height,
+		internalWidth:  internalSize(c, width),
+		internalHeight: internalSize(c, height),
+		flipY:          true,
+		clip:           image.Rect(0, 0, width, height),
 	}
 	return r, nil
 }
@@ -57,10 +65,14 @@ func NewFramebufferFromTexture(c *opengl.Context, texture *Texture) (*Framebuffe
 		return nil, err
 	}
 	w, h := texture.Size()
+	iw, ih := texture.InternalSize()
 	return &Framebuffer{
-		native: f,
-		width:  w,
-		height: h,
+		native:         f,
+		width:          w,
+		height:         h,
+		internalWidth:  iw,
+		internalHeight: ih,
+		clip:           image.Rect(0, 0, w, h),
 	}, nil
 }
 
@@ -68,23 +80,40 @@ func (f *Framebuffer) Size() (width, height int) {
 	return f.width, f.height
 }
 
-func (f *Framebuffer) Dispose(c *opengl.Context) {
+func (f *Framebuffer) Dispose(c *opengl.Context) error {
+	if err := FlushCommands(c); err != nil {
+		return err
+	}
 	c.DeleteFramebuffer(f.native)
+	return nil
+}
+
+// SetClip restricts drawing to the rectangle. The rectangle is clipped by the bounds of the framebuffer.
+//
+// Commands already enqueued are not affected.
+func (f *Framebuffer) SetClip(r image.Rectangle) {
+	f.clip = r.Intersect(image.Rect(0, 0, f.width, f.height))
 }
 
-func (f *Framebuffer) setAsViewport(c *opengl.Context) error {
-	width := internal.NextPowerOf2Int(f.width)
-	height := internal.NextPowerOf2Int(f.height)
-	return c.SetViewport(f.native, width, height)
+func (f *Framebuffer) setAsViewport(c *opengl.Context, clip image.Rectangle) error {
+	if err := c.SetViewport(f.native, f.internalWidth, f.internalHeight); err != nil {
+		return err
+	}
+	// The scissor box is in the window coordinates, whose origin is at the bottom-left
+	// for the flipped framebuffer.
+	y := clip.Min.Y
+	if f.flipY {
+		y = f.height - clip.Max.Y
+	}
+	c.SetScissor(clip.Min.X, y, clip.Dx(), clip.Dy())
+	return nil
 }
 
 func (f *Framebuffer) projectionMatrix() *[4][4]float64 {
-	width := internal.NextPowerOf2Int(f.width)
-	height := internal.NextPowerOf2Int(f.height)
-	m := orthoProjectionMatrix(0, width, 0, height)
+	m := orthoProjectionMatrix(0, f.internalWidth, 0, f.internalHeight)
 	if f.flipY {
 		m[1][1] *= -1
-		m[1][3] += float64(f.height) / float64(internal.NextPowerOf2Int(f.height)) * 2
+		m[1][3] += float64(f.height) / float64(f.internalHeight) * 2
 	}
 	return m
 }
@@ -95,55 +124,134 @@ type Matrix interface {
 
 type TextureQuads interface {
 	Len() int
-	Vertex(i int) (x0, y0, x1, y1 int)
-	Texture(i int) (u0, v0, u1, v1 int)
+	Vertex(i int) (x0, y0, x1, y1 float32)
+	Texture(i int) (u0, v0, u1, v1 float32)
 }
 
 func (f *Framebuffer) Fill(c *opengl.Context, r, g, b, a float64) error {
-	if err := f.setAsViewport(c); err != nil {
+	if err := FlushCommands(c); err != nil {
+		return err
+	}
+	if err := f.setAsViewport(c, f.clip); err != nil {
 		return err
 	}
 	return c.FillFramebuffer(r, g, b, a)
 }
 
-func (f *Framebuffer) DrawTexture(c *opengl.Context, t *Texture, quads TextureQuads, geo, clr Matrix) error {
-	if err := f.setAsViewport(c); err != nil {
+// EnqueueDrawTexture records the command to draw the texture.
+// The command is executed later at FlushCommands or before any other operation.
+func (f *Framebuffer) EnqueueDrawTexture(t *Texture, quads TextureQuads, geo, clr Matrix, mode opengl.CompositeMode) {
+	theCommandQueue.enqueueDrawTexture(f, t, quads, geo, clr, nil, nil, mode)
+}
+
+// EnqueueDrawTextureWithMask records the command to draw the texture through the mask.
+// The alpha values of the mask texture at maskRegion multiply the drawn colors at the same positions.
+func (f *Framebuffer) EnqueueDrawTextureWithMask(t *Texture, quads TextureQuads, geo, clr Matrix, mask *Texture, maskRegion image.Rectangle, mode opengl.CompositeMode) {
+	m := &textureMask{
+		texture: mask,
+		region:  maskRegion,
+	}
+	theCommandQueue.enqueueDrawTexture(f, t, quads, geo, clr, nil, m, mode)
+}
+
+// EnqueueDrawTextureWithShader records the command to draw the texture with the user-defined shader.
+// The textures in uniformTextures are passed to the shader as samplers.
+func (f *Framebuffer) EnqueueDrawTextureWithShader(t *Texture, quads TextureQuads, geo Matrix, s *Shader, uniformFloats map[string][]float32, uniformTextures map[string]*Texture, mode opengl.CompositeMode) {
+	u := &userShader{
+		shader:   s,
+		floats:   uniformFloats,
+		textures: uniformTextures,
+	}
+	theCommandQueue.enqueueDrawTexture(f, t, quads, geo, nil, u, nil, mode)
+}
+
+func (f *Framebuffer) drawTexture(c *opengl.Context, t *Texture, quads TextureQuads, geo, clr Matrix, mask *textureMask, clip image.Rectangle, mode opengl.CompositeMode) error {
+	if err := f.setAsViewport(c, clip); err != nil {
 		return err
 	}
 	p := f.projectionMatrix()
-	return shader.DrawTexture(c, t.native, p, quads, geo, clr)
+	return shader.DrawTexture(c, t.native, p, quads, geo, clr, mask.native(), mode)
+}
+
+func (f *Framebuffer) drawTextureWithShader(c *opengl.Context, t *Texture, quads TextureQuads, geo Matrix, s *userShader, clip image.Rectangle, mode opengl.CompositeMode) error {
+	if err := f.setAsViewport(c, clip); err != nil {
+		return err
+	}
+	p := f.projectionMatrix()
+	return shader.DrawTextureWithProgram(c, s.shader.program, t.native, p, quads, geo, s.uniforms(), mode)
+}
+
+// The limits of DrawTriangles.
+const (
+	TrianglesVerticesMaxNum = shader.TrianglesVerticesMaxNum
+	TrianglesIndicesMaxNum  = shader.TrianglesIndicesMaxNum
+)
+
+// DrawTriangles draws the triangles with the texture.
+// Each vertex consists of 8 floats: the position (x, y), the normalized texture coordinate (u, v)
+// and the alpha-premultiplied color (r, g, b, a) to multiply.
+func (f *Framebuffer) DrawTriangles(c *opengl.Context, t *Texture, vertices []float32, indices []uint16, clr Matrix, mode opengl.CompositeMode) error {
+	if err := FlushCommands(c); err != nil {
+		return err
+	}
+	if err := f.setAsViewport(c, f.clip); err != nil {
+		return err
+	}
+	p := f.projectionMatrix()
+	return shader.DrawTriangles(c, t.native, p, vertices, indices, clr, mode)
 }
 
 type Lines interface {
 	Len() int
-	Points(i int) (x0, y0, x1, y1 int)
+	Points(i int) (x0, y0, x1, y1 float32)
 	Color(i int) color.Color
 }
 
-func (f *Framebuffer) DrawLines(c *opengl.Context, lines Lines) error {
-	if err := f.setAsViewport(c); err != nil {
+func (f *Framebuffer) DrawLines(c *opengl.Context, lines Lines, mode opengl.CompositeMode) error {
+	if err := FlushCommands(c); err != nil {
+		return err
+	}
+	if err := f.setAsViewport(c, f.clip); err != nil {
 		return err
 	}
 	p := f.projectionMatrix()
-	return shader.DrawLines(c, p, lines)
+	return shader.DrawLines(c, p, lines, mode)
 }
 
 type Rects interface {
 	Len() int
-	Rect(i int) (x, y, width, height int)
+	Rect(i int) (x, y, width, height float32)
 	Color(i int) color.Color
 }
 
-func (f *Framebuffer) DrawFilledRects(c *opengl.Context, rects Rects) error {
-	if err := f.setAsViewport(c); err != nil {
+func (f *Framebuffer) DrawFilledRects(c *opengl.Context, rects Rects, mode opengl.CompositeMode) error {
+	if err := FlushCommands(c); err != nil {
+		return err
+	}
+	if err := f.setAsViewport(c, f.clip); err != nil {
 		return err
 	}
 	p := f.projectionMatrix()
-	return shader.DrawFilledRects(c, p, rects)
+	return shader.DrawFilledRects(c, p, rects, mode)
 }
 
+// Pixels returns the pixels of the framebuffer without padding: the length is 4 * width * height.
 func (f *Framebuffer) Pixels(c *opengl.Context) ([]uint8, error) {
+	if err := FlushCommands(c); err != nil {
+		return nil, err
+	}
+	pixels, err := c.FramebufferPixels(f.native, f.internalWidth, f.internalHeight)
+	if err != nil {
+		return nil, err
+	}
+	if f.width == f.internalWidth && f.height == f.internalHeight {
+		return pixels, nil
+	}
+	// Remove the padding.
 	w, h := f.Size()
-	w, h = internal.NextPowerOf2Int(w), internal.NextPowerOf2Int(h)
-	return c.FramebufferPixels(f.native, w, h)
+	result := make([]uint8, 4*w*h)
+	for j := 0; j < h; j++ {
+		copy(result[4*j*w:4*(j+1)*w], pixels[4*j*f.internalWidth:])
+	}
+	return result, nil
 }
@@ -12,6 +12,8 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// +build !headless
+
 package shader
 
 import (
@@ -19,6 +21,7 @@ import (
 	"fmt"
 	"github.com/hajimehoshi/ebiten/internal/opengl"
 	"image/color"
+	"math"
 )
 
 func glMatrix(m *[4][4]float64) []float32 {
@@ -30,21 +33,19 @@ func glMatrix(m *[4][4]float64) []float32 {
 	}
 }
 
-type Matrix interface {
-	Element(i, j int) float64
-}
-
-type TextureQuads interface {
-	Len() int
-	Vertex(i int) (x0, y0, x1, y1 int)
-	Texture(i int) (u0, v0, u1, v1 int)
-}
-
-var vertices = make([]int16, 0, 4*8*quadsMaxNum)
+var vertices = make([]float32, 0, 4*8*QuadsMaxNum)
 
 var initialized = false
 
-func DrawTexture(c *opengl.Context, texture opengl.Texture, projectionMatrix *[4][4]float64, quads TextureQuads, geo Matrix, color Matrix) error {
+// glColor returns the alpha-premultiplied color in [0, 1].
+func glColor(clr color.Color) (r, g, b, a float32) {
+	cr, cg, cb, ca := clr.RGBA()
+	const max = math.MaxUint16
+	return float32(cr) / max, float32(cg) / max, float32(cb) / max, float32(ca) / max
+}
+
+// DrawTexture draws the texture. mask can be nil.
+func DrawTexture(c *opengl.Context, texture opengl.Texture, projectionMatrix *[4][4]float64, quads TextureQuads, geo Matrix, color Matrix, mask *Mask, mode opengl.CompositeMode) error {
 	// TODO: WebGL doesn't seem to have Check gl.MAX_ELEMENTS_VERTICES or gl.MAX_ELEMENTS_INDICES so far.
 	// Let's use them to compare to len(quads) in the future.
 
@@ -58,13 +59,20 @@ func DrawTexture(c *opengl.Context, texture opengl.Texture, projectionMatrix *[4
 	if quads.Len() == 0 {
 		return nil
 	}
-	if quadsMaxNum < quads.Len() {
-		return errors.New(fmt.Sprintf("len(quads) must be equal to or less than %d", quadsMaxNum))
+	c.BlendFunc(mode)
+	if QuadsMaxNum < quads.Len() {
+		return errors.New(fmt.Sprintf("len(quads) must be equal to or less than %d", QuadsMaxNum))
 	}
 
-	f := useProgramForTexture(c, glMatrix(projectionMatrix), texture, geo, color)
+	f := useProgramForTexture(c, glMatrix(projectionMatrix), texture, geo, color, mask)
 	defer f.FinishProgram()
 
+	drawQuads(c, quads)
+	return nil
+}
+
+// drawQuads sends the vertices of the quads and draws them with the current program.
+func drawQuads(c *opengl.Context, quads TextureQuads) {
 	vertices := vertices[0:0]
 	num := 0
 	for i := 0; i < quads.Len(); i++ {
@@ -74,28 +82,21 @@ func DrawTexture(c *opengl.Context, texture opengl.Texture, projectionMatrix *[4
 			continue
 		}
 		vertices = append(vertices,
-			int16(x0), int16(y0), int16(u0), int16(v0),
-			int16(x1), int16(y0), int16(u1), int16(v0),
-			int16(x0), int16(y1), int16(u0), int16(v1),
-			int16(x1), int16(y1), int16(u1), int16(v1),
+			x0, y0, u0, v0,
+			x1, y0, u1, v0,
+			x0, y1, u0, v1,
+			x1, y1, u1, v1,
 		)
 		num++
 	}
 	if len(vertices) == 0 {
-		return nil
+		return
 	}
 	c.BufferSubData(c.ArrayBuffer, vertices)
 	c.DrawElements(c.Triangles, 6*num)
-	return nil
-}
-
-type Lines interface {
-	Len() int
-	Points(i int) (x0, y0, x1, y1 int)
-	Color(i int) color.Color
 }
 
-func DrawLines(c *opengl.Context, projectionMatrix *[4][4]float64, lines Lines) error {
+func DrawLines(c *opengl.Context, projectionMatrix *[4][4]float64, lines Lines, mode opengl.CompositeMode) error {
 	if !initialized {
 		if err := initialize(c); err != nil {
 			return err
@@ -106,6 +107,7 @@ func DrawLines(c *opengl.Context, projectionMatrix *[4][4]float64, lines Lines)
 	if lines.Len() == 0 {
 		return nil
 	}
+	c.BlendFunc(mode)
 
 	f := useProgramForLines(c, glMatrix(projectionMatrix))
 	defer f.FinishProgram()
@@ -117,10 +119,10 @@ func DrawLines(c *opengl.Context, projectionMatrix *[4][4]float64, lines Lines)
 		if x0 == x1 && y0 == y1 {
 			continue
 		}
-		r, g, b, a := lines.Color(i).RGBA()
+		r, g, b, a := glColor(lines.Color(i))
 		vertices = append(vertices,
-			int16(x0), int16(y0), int16(r), int16(g), int16(b), int16(a),
-			int16(x1), int16(y1), int16(r), int16(g), int16(b), int16(a),
+			x0, y0, r, g, b, a,
+			x1, y1, r, g, b, a,
 		)
 		num++
 	}
@@ -133,13 +135,7 @@ func DrawLines(c *opengl.Context, projectionMatrix *[4][4]float64, lines Lines)
 	return nil
 }
 
-type Rects interface {
-	Len() int
-	Rect(i int) (x, y, width, height int)
-	Color(i int) color.Color
-}
-
-func DrawFilledRects(c *opengl.Context, projectionMatrix *[4][4]float64, rects Rects) error {
+func DrawFilledRects(c *opengl.Context, projectionMatrix *[4][4]float64, rects Rects, mode opengl.CompositeMode) error {
 	if !initialized {
 		if err := initialize(c); err != nil {
 			return err
@@ -150,6 +146,7 @@ func DrawFilledRects(c *opengl.Context, projectionMatrix *[4][4]float64, rects R
 	if rects.Len() == 0 {
 		return nil
 	}
+	c.BlendFunc(mode)
 
 	f := useProgramForRects(c, glMatrix(projectionMatrix))
 	defer f.FinishProgram()
@@ -162,12 +159,12 @@ func DrawFilledRects(c *opengl.Context, projectionMatrix *[4][4]float64, rects R
 			continue
 		}
 		x0, y0, x1, y1 := x, y, x+w, y+h
-		r, g, b, a := rects.Color(i).RGBA()
+		r, g, b, a := glColor(rects.Color(i))
 		vertices = append(vertices,
-			int16(x0), int16(y0), int16(r), int16(g), int16(b), int16(a),
-			int16(x1), int16(y0), int16(r), int16(g), int16(b), int16(a),
-			int16(x0), int16(y1), int16(r), int16(g), int16(b), int16(a),
-			int16(x1), int16(y1), int16(r), int16(g), int16(b), int16(a),
+			x0, y0, r, g, b, a,
+			x1, y0, r, g, b, a,
+			x0, y1, r, g, b, a,
+			x1, y1, r, g, b, a,
 		)
 		num++
 	}
@@ -179,3 +176,31 @@ func DrawFilledRects(c *opengl.Context, projectionMatrix *[4][4]float64, rects R
 
 	return nil
 }
+
+func DrawTriangles(c *opengl.Context, texture opengl.Texture, projectionMatrix *[4][4]float64, vertices []float32, indices []uint16, color Matrix, mode opengl.CompositeMode) error {
+	if !initialized {
+		if err := initialize(c); err != nil {
+			return err
+		}
+		initialized = true
+	}
+
+	if len(indices) == 0 {
+		return nil
+	}
+	c.BlendFunc(mode)
+	if TrianglesVerticesMaxNum < len(vertices)/TrianglesVertexSize {
+		return errors.New(fmt.Sprintf("the number of vertices must be equal to or less than %d", TrianglesVerticesMaxNum))
+	}
+	if TrianglesIndicesMaxNum < len(indices) {
+		return errors.New(fmt.Sprintf("len(indices) must be equal to or less than %d", TrianglesIndicesMaxNum))
+	}
+
+	f := useProgramForTriangles(c, glMatrix(projectionMatrix), texture, color)
+	defer f.FinishProgram()
+
+	c.BufferSubData(c.ArrayBuffer, vertices)
+	c.BufferSubData(c.ElementArrayBuffer, indices)
+	c.DrawElements(c.Triangles, len(indices))
+	return nil
+}
@@ -0,0 +1,293 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build headless
+
+package shader
+
+import (
+	"github.com/hajimehoshi/ebiten/internal/opengl"
+	"math"
+)
+
+// This file is a software implementation of the programs in shader.go.
+// Vertices are given in float32 as the OpenGL version and transformed in float64.
+
+// attributes are the values interpolated for each fragment.
+// For textures, they are the texture coordinate, the color to multiply and the position on the destination.
+type attributes [8]float64
+
+type vertex struct {
+	x, y float64
+	attr attributes
+}
+
+type fragmentFunc func(x, y int, attr *attributes)
+
+func transform(projectionMatrix *[4][4]float64, width, height int, x, y float64) (float64, float64) {
+	p := projectionMatrix
+	nx := p[0][0]*x + p[0][1]*y + p[0][3]
+	ny := p[1][0]*x + p[1][1]*y + p[1][3]
+	return (nx + 1) / 2 * float64(width), (ny + 1) / 2 * float64(height)
+}
+
+func edge(v0, v1 *vertex, x, y float64) float64 {
+	return (v1.x-v0.x)*(y-v0.y) - (v1.y-v0.y)*(x-v0.x)
+}
+
+// isTopLeft reports whether a fragment exactly on the edge (v0, v1) belongs to the triangle.
+// Exactly one of two triangles sharing an edge owns the fragments on it.
+func isTopLeft(v0, v1 *vertex) bool {
+	dx, dy := v1.x-v0.x, v1.y-v0.y
+	return 0 < dy || (dy == 0 && dx < 0)
+}
+
+func rasterizeTriangle(width, height int, v0, v1, v2 *vertex, f fragmentFunc) {
+	area := edge(v0, v1, v2.x, v2.y)
+	if area == 0 {
+		return
+	}
+	if area < 0 {
+		v1, v2 = v2, v1
+		area = -area
+	}
+	minX := int(math.Max(math.Floor(math.Min(v0.x, math.Min(v1.x, v2.x))), 0))
+	minY := int(math.Max(math.Floor(math.Min(v0.y, math.Min(v1.y, v2.y))), 0))
+	maxX := int(math.Min(math.Ceil(math.Max(v0.x, math.Max(v1.x, v2.x))), float64(width)))
+	maxY := int(math.Min(math.Ceil(math.Max(v0.y, math.Max(v1.y, v2.y))), float64(height)))
+	tl0, tl1, tl2 := isTopLeft(v1, v2), isTopLeft(v2, v0), isTopLeft(v0, v1)
+	attr := attributes{}
+	for j := minY; j < maxY; j++ {
+		y := float64(j) + 0.5
+		for i := minX; i < maxX; i++ {
+			x := float64(i) + 0.5
+			w0 := edge(v1, v2, x, y)
+			w1 := edge(v2, v0, x, y)
+			w2 := edge(v0, v1, x, y)
+			if w0 < 0 || w1 < 0 || w2 < 0 {
+				continue
+			}
+			if (w0 == 0 && !tl0) || (w1 == 0 && !tl1) || (w2 == 0 && !tl2) {
+				continue
+			}
+			for k := range attr {
+				attr[k] = (w0*v0.attr[k] + w1*v1.attr[k] + w2*v2.attr[k]) / area
+			}
+			f(i, j, &attr)
+		}
+	}
+}
+
+func rasterizeQuad(width, height int, vertices *[4]vertex, f fragmentFunc) {
+	rasterizeTriangle(width, height, &vertices[0], &vertices[1], &vertices[2], f)
+	rasterizeTriangle(width, height, &vertices[1], &vertices[2], &vertices[3], f)
+}
+
+// rasterizeLine draws a line with the diamond-exit rule: the last pixel is not drawn.
+func rasterizeLine(width, height int, v0, v1 *vertex, f fragmentFunc) {
+	dx, dy := v1.x-v0.x, v1.y-v0.y
+	n := int(math.Floor(math.Max(math.Abs(dx), math.Abs(dy)) + 0.5))
+	for k := 0; k < n; k++ {
+		t := float64(k) / float64(n)
+		i := int(math.Floor(v0.x + dx*t))
+		j := int(math.Floor(v0.y + dy*t))
+		if i < 0 || j < 0 || width <= i || height <= j {
+			continue
+		}
+		f(i, j, &v0.attr)
+	}
+}
+
+func isIdentityColorMatrix(color Matrix) bool {
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 5; j++ {
+			e := 0.0
+			if i == j {
+				e = 1
+			}
+			if float32(color.Element(i, j)) != float32(e) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func clamp(v float64) float64 {
+	return math.Min(math.Max(v, 0), 1)
+}
+
+func applyColorMatrix(color Matrix, r, g, b, a float64) (float64, float64, float64, float64) {
+	// Un-premultiply alpha
+	if a == 0 {
+		r, g, b = 0, 0, 0
+	} else {
+		r, g, b = r/a, g/a, b/a
+	}
+	// Apply the color matrix
+	src := [4]float64{r, g, b, a}
+	dst := [4]float64{}
+	for i := 0; i < 4; i++ {
+		v := color.Element(i, 4)
+		for j := 0; j < 4; j++ {
+			v += color.Element(i, j) * src[j]
+		}
+		dst[i] = clamp(v)
+	}
+	// Premultiply alpha
+	a = dst[3]
+	return dst[0] * a, dst[1] * a, dst[2] * a, a
+}
+
+// textureFragment returns the function to do the same as shaderFragmentTexture and shaderFragmentTextureMask.
+// attr[2:6] is the alpha-premultiplied color to multiply, and attr[6:8] is the position on the destination.
+func textureFragment(c *opengl.Context, texture opengl.Texture, color Matrix, mask *Mask) fragmentFunc {
+	identity := isIdentityColorMatrix(color)
+	return func(x, y int, attr *attributes) {
+		r, g, b, a := c.TextureColor(texture, attr[0], attr[1])
+		if !identity {
+			r, g, b, a = applyColorMatrix(color, r, g, b, a)
+		}
+		m := 1.0
+		if mask != nil {
+			m = maskAlpha(c, mask, attr[6], attr[7])
+		}
+		c.BlendColor(x, y, r*attr[2]*m, g*attr[3]*m, b*attr[4]*m, a*attr[5]*m)
+	}
+}
+
+func maskAlpha(c *opengl.Context, mask *Mask, x, y float64) float64 {
+	r := mask.Region
+	x += float64(r.Min.X)
+	y += float64(r.Min.Y)
+	if x < float64(r.Min.X) || y < float64(r.Min.Y) || float64(r.Max.X) <= x || float64(r.Max.Y) <= y {
+		return 0
+	}
+	_, _, _, a := c.TextureColor(mask.Texture, x/float64(mask.Width), y/float64(mask.Height))
+	return a
+}
+
+func DrawTexture(c *opengl.Context, texture opengl.Texture, projectionMatrix *[4][4]float64, quads TextureQuads, geo Matrix, color Matrix, mask *Mask, mode opengl.CompositeMode) error {
+	if quads.Len() == 0 {
+		return nil
+	}
+	c.BlendFunc(mode)
+	width, height := c.ViewportSize()
+	ma, mb, mc, md := geo.Element(0, 0), geo.Element(0, 1), geo.Element(1, 0), geo.Element(1, 1)
+	tx, ty := geo.Element(0, 2), geo.Element(1, 2)
+	f := textureFragment(c, texture, color, mask)
+	for i := 0; i < quads.Len(); i++ {
+		x0, y0, x1, y1 := quads.Vertex(i)
+		u0, v0, u1, v1 := quads.Texture(i)
+		if x0 == x1 || y0 == y1 || u0 == u1 || v0 == v1 {
+			continue
+		}
+		points := [4][4]float32{
+			{x0, y0, u0, v0},
+			{x1, y0, u1, v0},
+			{x0, y1, u0, v1},
+			{x1, y1, u1, v1},
+		}
+		vertices := [4]vertex{}
+		for k, p := range points {
+			x, y := float64(p[0]), float64(p[1])
+			x, y = ma*x+mb*y+tx, mc*x+md*y+ty
+			vertices[k].x, vertices[k].y = transform(projectionMatrix, width, height, x, y)
+			vertices[k].attr = attributes{float64(p[2]), float64(p[3]), 1, 1, 1, 1, x, y}
+		}
+		rasterizeQuad(width, height, &vertices, f)
+	}
+	return nil
+}
+
+func blendFragment(c *opengl.Context) fragmentFunc {
+	return func(x, y int, attr *attributes) {
+		c.BlendColor(x, y, attr[0], attr[1], attr[2], attr[3])
+	}
+}
+
+func colorAttr(r, g, b, a uint32) attributes {
+	const max = math.MaxUint16
+	return attributes{float64(uint16(r)) / max, float64(uint16(g)) / max, float64(uint16(b)) / max, float64(uint16(a)) / max}
+}
+
+func DrawLines(c *opengl.Context, projectionMatrix *[4][4]float64, lines Lines, mode opengl.CompositeMode) error {
+	if lines.Len() == 0 {
+		return nil
+	}
+	c.BlendFunc(mode)
+	width, height := c.ViewportSize()
+	f := blendFragment(c)
+	for i := 0; i < lines.Len(); i++ {
+		x0, y0, x1, y1 := lines.Points(i)
+		if x0 == x1 && y0 == y1 {
+			continue
+		}
+		attr := colorAttr(lines.Color(i).RGBA())
+		vertices := [2]vertex{}
+		for k, p := range [2][2]float32{{x0, y0}, {x1, y1}} {
+			// The same as the vertex shader for lines: Pixel centers are the end points.
+			x, y := float64(p[0])+0.5, float64(p[1])+0.5
+			vertices[k].x, vertices[k].y = transform(projectionMatrix, width, height, x, y)
+			vertices[k].attr = attr
+		}
+		rasterizeLine(width, height, &vertices[0], &vertices[1], f)
+	}
+	return nil
+}
+
+func DrawFilledRects(c *opengl.Context, projectionMatrix *[4][4]float64, rects Rects, mode opengl.CompositeMode) error {
+	if rects.Len() == 0 {
+		return nil
+	}
+	c.BlendFunc(mode)
+	width, height := c.ViewportSize()
+	f := blendFragment(c)
+	for i := 0; i < rects.Len(); i++ {
+		x, y, w, h := rects.Rect(i)
+		if w == 0 || h == 0 {
+			continue
+		}
+		x0, y0, x1, y1 := x, y, x+w, y+h
+		attr := colorAttr(rects.Color(i).RGBA())
+		vertices := [4]vertex{}
+		for k, p := range [4][2]float32{{x0, y0}, {x1, y0}, {x0, y1}, {x1, y1}} {
+			vertices[k].x, vertices[k].y = transform(projectionMatrix, width, height, float64(p[0]), float64(p[1]))
+			vertices[k].attr = attr
+		}
+		rasterizeQuad(width, height, &vertices, f)
+	}
+	return nil
+}
+
+func DrawTriangles(c *opengl.Context, texture opengl.Texture, projectionMatrix *[4][4]float64, vertices []float32, indices []uint16, color Matrix, mode opengl.CompositeMode) error {
+	if len(indices) == 0 {
+		return nil
+	}
+	c.BlendFunc(mode)
+	width, height := c.ViewportSize()
+	f := textureFragment(c, texture, color, nil)
+	vs := make([]vertex, len(vertices)/TrianglesVertexSize)
+	for i := range vs {
+		v := vertices[i*TrianglesVertexSize : (i+1)*TrianglesVertexSize]
+		vs[i].x, vs[i].y = transform(projectionMatrix, width, height, float64(v[0]), float64(v[1]))
+		for k := 0; k < TrianglesVertexSize-2; k++ {
+			vs[i].attr[k] = float64(v[k+2])
+		}
+	}
+	for i := 0; i+2 < len(indices); i += 3 {
+		rasterizeTriangle(width, height, &vs[indices[i]], &vs[indices[i+1]], &vs[indices[i+2]], f)
+	}
+	return nil
+}
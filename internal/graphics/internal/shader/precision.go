@@ -0,0 +1,54 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shader
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	precisionStatementRe = regexp.MustCompile(`\bprecision\s+(highp|mediump|lowp)\s+\w+\s*;`)
+	highpRe              = regexp.MustCompile(`\bhighp\b`)
+)
+
+// adjustFragmentShader returns the user-defined fragment shader source adjusted to the GLSL of the context.
+//
+// If highp is supported, the default precision of float is highp.
+// Otherwise, the source is compiled either as desktop GLSL, which doesn't accept precision statements,
+// or as GLSL ES without highp in fragment shaders. The precision statements are moved into
+// an #ifdef GL_ES block, and the qualifiers are defined as empty macros on desktops.
+// highp is replaced with mediump, which doesn't matter on desktops.
+//
+// In both cases, #line keeps the line numbers in error messages the same as the given source.
+func adjustFragmentShader(source string, highpSupported bool) string {
+	if highpSupported {
+		return "precision highp float;\n#line 1\n" + source
+	}
+	source = highpRe.ReplaceAllString(source, "mediump")
+	precisions := []string{"precision mediump float;"}
+	source = precisionStatementRe.ReplaceAllStringFunc(source, func(s string) string {
+		precisions = append(precisions, s)
+		return ""
+	})
+	return "#ifdef GL_ES\n" +
+		strings.Join(precisions, "\n") + "\n" +
+		"#else\n" +
+		"#define highp\n" +
+		"#define mediump\n" +
+		"#define lowp\n" +
+		"#endif\n" +
+		"#line 1\n" + source
+}
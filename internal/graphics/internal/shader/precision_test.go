@@ -0,0 +1,82 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shader
+
+import (
+	"strings"
+	"testing"
+)
+
+// lineOf returns the line number of s in src counted from the last #line 1 directive.
+func lineOf(src, s string) int {
+	i := strings.Index(src, "#line 1\n")
+	if i < 0 {
+		return -1
+	}
+	src = src[i+len("#line 1\n"):]
+	j := strings.Index(src, s)
+	if j < 0 {
+		return -1
+	}
+	return strings.Count(src[:j], "\n") + 1
+}
+
+func TestAdjustFragmentShader(t *testing.T) {
+	const src = `precision mediump float;
+uniform sampler2D texture;
+varying highp vec2 vertex_out_tex_coord;
+
+void main(void) {
+  lowp vec4 c = texture2D(texture, vertex_out_tex_coord);
+  gl_FragColor = c;
+}
+`
+	for _, highp := range []bool{true, false} {
+		got := adjustFragmentShader(src, highp)
+		if got, want := lineOf(got, "gl_FragColor"), 7; got != want {
+			t.Errorf("highp: %t: the line of gl_FragColor: got %d, want: %d", highp, got, want)
+		}
+	}
+
+	got := adjustFragmentShader(src, true)
+	if !strings.HasPrefix(got, "precision highp float;\n") {
+		t.Errorf("highp: true: the default precision must be highp: %q", got)
+	}
+	if !strings.HasSuffix(got, src) {
+		t.Errorf("highp: true: the source must not be changed: %q", got)
+	}
+
+	got = adjustFragmentShader(src, false)
+	body := got[strings.Index(got, "#line 1\n"):]
+	// The precision statements are valid only in GLSL ES.
+	if strings.Contains(body, "precision") {
+		t.Errorf("highp: false: the precision statement must be moved: %q", got)
+	}
+	if !strings.Contains(got, "#ifdef GL_ES\nprecision mediump float;\nprecision mediump float;\n#else\n") {
+		t.Errorf("highp: false: the precision statements must be in the GL_ES block: %q", got)
+	}
+	if strings.Contains(body, "highp") {
+		t.Errorf("highp: false: highp must be replaced with mediump: %q", got)
+	}
+	// The qualifiers before the type names are kept and defined as empty macros on desktops.
+	if !strings.Contains(body, "varying mediump vec2 vertex_out_tex_coord;") || !strings.Contains(body, "lowp vec4 c") {
+		t.Errorf("highp: false: the qualifiers must be kept: %q", got)
+	}
+	for _, q := range []string{"highp", "mediump", "lowp"} {
+		if !strings.Contains(got, "#else\n") || !strings.Contains(got[strings.Index(got, "#else\n"):], "#define "+q+"\n") {
+			t.Errorf("highp: false: %s must be defined as an empty macro: %q", q, got)
+		}
+	}
+}
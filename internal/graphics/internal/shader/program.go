@@ -12,29 +12,29 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// +build !headless
+
 package shader
 
 import (
 	"github.com/hajimehoshi/ebiten/internal/opengl"
-	"math"
 )
 
 var (
-	indexBufferLines opengl.Buffer
-	indexBufferQuads opengl.Buffer
+	indexBufferLines     opengl.Buffer
+	indexBufferQuads     opengl.Buffer
+	indexBufferTriangles opengl.Buffer
 )
 
 var (
-	programTexture   opengl.Program
-	programSolidRect opengl.Program
-	programSolidLine opengl.Program
+	programTexture     opengl.Program
+	programTextureMask opengl.Program
+	programSolidRect   opengl.Program
+	programSolidLine   opengl.Program
+	programTriangles   opengl.Program
 )
 
-const indicesNum = math.MaxUint16 + 1
-const quadsMaxNum = indicesNum / 6
-
 // unsafe.SizeOf can't be used because unsafe doesn't work with GopherJS.
-const int16Size = 2
 const float32Size = 4
 
 func initialize(c *opengl.Context) error {
@@ -44,6 +44,12 @@ func initialize(c *opengl.Context) error {
 	}
 	defer c.DeleteShader(shaderVertexModelviewNative)
 
+	shaderVertexModelviewMaskNative, err := c.NewShader(c.VertexShader, shader(c, shaderVertexModelviewMask))
+	if err != nil {
+		return err
+	}
+	defer c.DeleteShader(shaderVertexModelviewMaskNative)
+
 	shaderVertexColorNative, err := c.NewShader(c.VertexShader, shader(c, shaderVertexColor))
 	if err != nil {
 		return err
@@ -56,12 +62,24 @@ func initialize(c *opengl.Context) error {
 	}
 	defer c.DeleteShader(shaderVertexColorLineNative)
 
+	shaderVertexTrianglesNative, err := c.NewShader(c.VertexShader, shader(c, shaderVertexTriangles))
+	if err != nil {
+		return err
+	}
+	defer c.DeleteShader(shaderVertexTrianglesNative)
+
 	shaderFragmentTextureNative, err := c.NewShader(c.FragmentShader, shader(c, shaderFragmentTexture))
 	if err != nil {
 		return err
 	}
 	defer c.DeleteShader(shaderFragmentTextureNative)
 
+	shaderFragmentTextureMaskNative, err := c.NewShader(c.FragmentShader, shader(c, shaderFragmentTextureMask))
+	if err != nil {
+		return err
+	}
+	defer c.DeleteShader(shaderFragmentTextureMaskNative)
+
 	shaderFragmentSolidNative, err := c.NewShader(c.FragmentShader, shader(c, shaderFragmentSolid))
 	if err != nil {
 		return err
@@ -76,6 +94,14 @@ func initialize(c *opengl.Context) error {
 		return err
 	}
 
+	programTextureMask, err = c.NewProgram([]opengl.Shader{
+		shaderVertexModelviewMaskNative,
+		shaderFragmentTextureMaskNative,
+	})
+	if err != nil {
+		return err
+	}
+
 	programSolidRect, err = c.NewProgram([]opengl.Shader{
 		shaderVertexColorNative,
 		shaderFragmentSolidNative,
@@ -92,12 +118,21 @@ func initialize(c *opengl.Context) error {
 		return err
 	}
 
-	// 16 [bytse] is an arbitrary number which seems enough to draw anything. Fix this if necessary.
-	const stride = 16
-	c.NewBuffer(c.ArrayBuffer, 4*stride*quadsMaxNum, c.DynamicDraw)
+	programTriangles, err = c.NewProgram([]opengl.Shader{
+		shaderVertexTrianglesNative,
+		shaderFragmentTextureNative,
+	})
+	if err != nil {
+		return err
+	}
+
+	// 32 [bytes] per vertex is enough for all the vertex formats:
+	// 4 floats for textures, 6 floats for lines and rects, and 8 floats for triangles.
+	const stride = 8 * float32Size
+	c.NewBuffer(c.ArrayBuffer, 4*stride*QuadsMaxNum, c.DynamicDraw)
 
-	indices := make([]uint16, 6*quadsMaxNum)
-	for i := uint16(0); i < quadsMaxNum; i++ {
+	indices := make([]uint16, 6*QuadsMaxNum)
+	for i := uint16(0); i < QuadsMaxNum; i++ {
 		indices[6*i+0] = 4*i + 0
 		indices[6*i+1] = 4*i + 1
 		indices[6*i+2] = 4*i + 2
@@ -113,6 +148,9 @@ func initialize(c *opengl.Context) error {
 	}
 	indexBufferLines = c.NewBuffer(c.ElementArrayBuffer, indices, c.StaticDraw)
 
+	const uint16Size = 2
+	indexBufferTriangles = c.NewBuffer(c.ElementArrayBuffer, uint16Size*TrianglesIndicesMaxNum, c.DynamicDraw)
+
 	return nil
 }
 
@@ -124,32 +162,39 @@ func (p programFinisher) FinishProgram() {
 	p()
 }
 
-func useProgramForTexture(c *opengl.Context, projectionMatrix []float32, texture opengl.Texture, geo Matrix, color Matrix) programFinisher {
-	if !lastProgram.Equals(programTexture) {
-		c.UseProgram(programTexture)
-		lastProgram = programTexture
-	}
+func useProgramForTexture(c *opengl.Context, projectionMatrix []float32, texture opengl.Texture, geo Matrix, color Matrix, mask *Mask) programFinisher {
 	program := programTexture
+	if mask != nil {
+		program = programTextureMask
+	}
+	if !lastProgram.Equals(program) {
+		c.UseProgram(program)
+		lastProgram = program
+	}
 
 	c.BindElementArrayBuffer(indexBufferQuads)
 
 	c.UniformFloats(program, "projection_matrix", projectionMatrix)
-
-	ma := float32(geo.Element(0, 0))
-	mb := float32(geo.Element(0, 1))
-	mc := float32(geo.Element(1, 0))
-	md := float32(geo.Element(1, 1))
-	tx := float32(geo.Element(0, 2))
-	ty := float32(geo.Element(1, 2))
-	glModelviewMatrix := []float32{
-		ma, mc, 0, 0,
-		mb, md, 0, 0,
-		0, 0, 1, 0,
-		tx, ty, 0, 1,
-	}
-	c.UniformFloats(program, "modelview_matrix", glModelviewMatrix)
+	c.UniformFloats(program, "modelview_matrix", glModelviewMatrix(geo))
 	c.UniformInt(program, "texture", 0)
+	setColorMatrix(c, program, color)
+
+	if mask != nil {
+		r := mask.Region
+		c.UniformInt(program, "mask", 1)
+		c.UniformFloats(program, "mask_region", []float32{float32(r.Min.X), float32(r.Min.Y), float32(r.Max.X), float32(r.Max.Y)})
+		c.UniformFloats(program, "mask_size", []float32{float32(mask.Width), float32(mask.Height)})
+		c.BindTextureUnit(mask.Texture, 1)
+	}
 
+	// We don't have to call gl.ActiveTexture here: GL_TEXTURE0 is the default active texture
+	// See also: https://www.opengl.org/sdk/docs/man2/xhtml/glActiveTexture.xml
+	c.BindTexture(texture)
+
+	return enableTextureVertexAttribs(c, program)
+}
+
+func setColorMatrix(c *opengl.Context, program opengl.Program, color Matrix) {
 	e := [4][5]float32{}
 	for i := 0; i < 4; i++ {
 		for j := 0; j < 5; j++ {
@@ -168,16 +213,30 @@ func useProgramForTexture(c *opengl.Context, projectionMatrix []float32, texture
 		e[0][4], e[1][4], e[2][4], e[3][4],
 	}
 	c.UniformFloats(program, "color_matrix_translation", glColorMatrixTranslation)
+}
 
-	// We don't have to call gl.ActiveTexture here: GL_TEXTURE0 is the default active texture
-	// See also: https://www.opengl.org/sdk/docs/man2/xhtml/glActiveTexture.xml
-	c.BindTexture(texture)
+func glModelviewMatrix(geo Matrix) []float32 {
+	ma := float32(geo.Element(0, 0))
+	mb := float32(geo.Element(0, 1))
+	mc := float32(geo.Element(1, 0))
+	md := float32(geo.Element(1, 1))
+	tx := float32(geo.Element(0, 2))
+	ty := float32(geo.Element(1, 2))
+	return []float32{
+		ma, mc, 0, 0,
+		mb, md, 0, 0,
+		0, 0, 1, 0,
+		tx, ty, 0, 1,
+	}
+}
 
+// enableTextureVertexAttribs enables the attributes of shaderVertexModelview.
+func enableTextureVertexAttribs(c *opengl.Context, program opengl.Program) programFinisher {
 	c.EnableVertexAttribArray(program, "vertex")
 	c.EnableVertexAttribArray(program, "tex_coord")
 
-	c.VertexAttribPointer(program, "vertex", true, false, int16Size*4, 2, uintptr(int16Size*0))
-	c.VertexAttribPointer(program, "tex_coord", true, true, int16Size*4, 2, uintptr(int16Size*2))
+	c.VertexAttribPointer(program, "vertex", float32Size*4, 2, uintptr(float32Size*0))
+	c.VertexAttribPointer(program, "tex_coord", float32Size*4, 2, uintptr(float32Size*2))
 
 	return func() {
 		c.DisableVertexAttribArray(program, "tex_coord")
@@ -199,9 +258,8 @@ func useProgramForLines(c *opengl.Context, projectionMatrix []float32) programFi
 	c.EnableVertexAttribArray(program, "vertex")
 	c.EnableVertexAttribArray(program, "color")
 
-	// TODO: Change to floats?
-	c.VertexAttribPointer(program, "vertex", true, false, int16Size*6, 2, uintptr(int16Size*0))
-	c.VertexAttribPointer(program, "color", false, true, int16Size*6, 4, uintptr(int16Size*2))
+	c.VertexAttribPointer(program, "vertex", float32Size*6, 2, uintptr(float32Size*0))
+	c.VertexAttribPointer(program, "color", float32Size*6, 4, uintptr(float32Size*2))
 
 	return func() {
 		c.DisableVertexAttribArray(program, "color")
@@ -223,11 +281,40 @@ func useProgramForRects(c *opengl.Context, projectionMatrix []float32) programFi
 	c.EnableVertexAttribArray(program, "vertex")
 	c.EnableVertexAttribArray(program, "color")
 
-	c.VertexAttribPointer(program, "vertex", true, false, int16Size*6, 2, uintptr(int16Size*0))
-	c.VertexAttribPointer(program, "color", false, true, int16Size*6, 4, uintptr(int16Size*2))
+	c.VertexAttribPointer(program, "vertex", float32Size*6, 2, uintptr(float32Size*0))
+	c.VertexAttribPointer(program, "color", float32Size*6, 4, uintptr(float32Size*2))
+
+	return func() {
+		c.DisableVertexAttribArray(program, "color")
+		c.DisableVertexAttribArray(program, "vertex")
+	}
+}
+
+func useProgramForTriangles(c *opengl.Context, projectionMatrix []float32, texture opengl.Texture, color Matrix) programFinisher {
+	if !lastProgram.Equals(programTriangles) {
+		c.UseProgram(programTriangles)
+		lastProgram = programTriangles
+	}
+	program := programTriangles
+
+	c.BindElementArrayBuffer(indexBufferTriangles)
+
+	c.UniformFloats(program, "projection_matrix", projectionMatrix)
+	c.UniformInt(program, "texture", 0)
+	setColorMatrix(c, program, color)
+	c.BindTexture(texture)
+
+	c.EnableVertexAttribArray(program, "vertex")
+	c.EnableVertexAttribArray(program, "tex_coord")
+	c.EnableVertexAttribArray(program, "color")
+
+	c.VertexAttribPointer(program, "vertex", float32Size*8, 2, uintptr(float32Size*0))
+	c.VertexAttribPointer(program, "tex_coord", float32Size*8, 2, uintptr(float32Size*2))
+	c.VertexAttribPointer(program, "color", float32Size*8, 4, uintptr(float32Size*4))
 
 	return func() {
 		c.DisableVertexAttribArray(program, "color")
+		c.DisableVertexAttribArray(program, "tex_coord")
 		c.DisableVertexAttribArray(program, "vertex")
 	}
 }
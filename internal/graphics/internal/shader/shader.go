@@ -12,6 +12,8 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// +build !headless
+
 package shader
 
 import (
@@ -23,9 +25,12 @@ type shaderId int
 
 const (
 	shaderVertexModelview shaderId = iota
+	shaderVertexModelviewMask
 	shaderVertexColor
 	shaderVertexColorLine
+	shaderVertexTriangles
 	shaderFragmentTexture
+	shaderFragmentTextureMask
 	shaderFragmentSolid
 )
 
@@ -45,11 +50,31 @@ uniform highp mat4 modelview_matrix;
 attribute highp vec2 vertex;
 attribute highp vec2 tex_coord;
 varying highp vec2 vertex_out_tex_coord;
+varying lowp vec4 vertex_out_color;
 
 void main(void) {
   vertex_out_tex_coord = tex_coord;
+  vertex_out_color = vec4(1, 1, 1, 1);
   gl_Position = projection_matrix * modelview_matrix * vec4(vertex, 0, 1);
 }
+`,
+	shaderVertexModelviewMask: `
+uniform highp mat4 projection_matrix;
+uniform highp mat4 modelview_matrix;
+attribute highp vec2 vertex;
+attribute highp vec2 tex_coord;
+varying highp vec2 vertex_out_tex_coord;
+// vertex_out_dst_position is the position on the destination in pixels.
+varying highp vec2 vertex_out_dst_position;
+varying lowp vec4 vertex_out_color;
+
+void main(void) {
+  vertex_out_tex_coord = tex_coord;
+  vertex_out_color = vec4(1, 1, 1, 1);
+  highp vec4 position = modelview_matrix * vec4(vertex, 0, 1);
+  vertex_out_dst_position = position.xy;
+  gl_Position = projection_matrix * position;
+}
 `,
 	shaderVertexColor: `
 uniform highp mat4 projection_matrix;
@@ -72,12 +97,56 @@ void main(void) {
   vertex_out_color = color;
   gl_Position = projection_matrix * vec4(vertex + vec2(0.5, 0.5), 0, 1);
 }
+`,
+	shaderVertexTriangles: `
+uniform highp mat4 projection_matrix;
+attribute highp vec2 vertex;
+attribute highp vec2 tex_coord;
+attribute lowp vec4 color;
+varying highp vec2 vertex_out_tex_coord;
+varying lowp vec4 vertex_out_color;
+
+void main(void) {
+  vertex_out_tex_coord = tex_coord;
+  vertex_out_color = color;
+  gl_Position = projection_matrix * vec4(vertex, 0, 1);
+}
 `,
 	shaderFragmentTexture: `
 uniform lowp sampler2D texture;
 uniform lowp mat4 color_matrix;
 uniform lowp vec4 color_matrix_translation;
 varying highp vec2 vertex_out_tex_coord;
+// vertex_out_color is an alpha-premultiplied color to multiply.
+varying lowp vec4 vertex_out_color;
+
+void main(void) {
+  lowp vec4 color = texture2D(texture, vertex_out_tex_coord);
+
+  if (color_matrix != mat4(1.0) || color_matrix_translation != vec4(0.0)) {
+    // Un-premultiply alpha
+    color.rgb /= color.a;
+    // Apply the color matrix
+    color = (color_matrix * color) + color_matrix_translation;
+    color = clamp(color, 0.0, 1.0);
+    // Premultiply alpha
+    color.rgb *= color.a;
+  }
+
+  gl_FragColor = color * vertex_out_color;
+}
+`,
+	shaderFragmentTextureMask: `
+uniform lowp sampler2D texture;
+uniform lowp sampler2D mask;
+// mask_region is the region of the mask (x0, y0, x1, y1) and mask_size is the size of the mask texture in pixels.
+uniform highp vec4 mask_region;
+uniform highp vec2 mask_size;
+uniform lowp mat4 color_matrix;
+uniform lowp vec4 color_matrix_translation;
+varying highp vec2 vertex_out_tex_coord;
+varying highp vec2 vertex_out_dst_position;
+varying lowp vec4 vertex_out_color;
 
 void main(void) {
   lowp vec4 color = texture2D(texture, vertex_out_tex_coord);
@@ -92,7 +161,12 @@ void main(void) {
     color.rgb *= color.a;
   }
 
-  gl_FragColor = color;
+  highp vec2 p = vertex_out_dst_position + mask_region.xy;
+  lowp float alpha = 0.0;
+  if (all(greaterThanEqual(p, mask_region.xy)) && all(lessThan(p, mask_region.zw))) {
+    alpha = texture2D(mask, p / mask_size).a;
+  }
+  gl_FragColor = color * vertex_out_color * alpha;
 }
 `,
 	shaderFragmentSolid: `
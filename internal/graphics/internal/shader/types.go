@@ -0,0 +1,81 @@
+// Copyright 2014 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shader
+
+import (
+	"github.com/hajimehoshi/ebiten/internal/opengl"
+	"image"
+	"image/color"
+	"math"
+)
+
+const indicesNum = math.MaxUint16 + 1
+
+// QuadsMaxNum is the maximum number of quads drawn at once.
+const QuadsMaxNum = indicesNum / 6
+
+// TrianglesVerticesMaxNum and TrianglesIndicesMaxNum are the maximum numbers of vertices and indices
+// drawn at once by DrawTriangles.
+const (
+	TrianglesVerticesMaxNum = 4 * QuadsMaxNum
+	TrianglesIndicesMaxNum  = indicesNum
+)
+
+// TrianglesVertexSize is the number of floats per vertex for DrawTriangles:
+// the position (x, y), the normalized texture coordinate (u, v) and the alpha-premultiplied color (r, g, b, a).
+const TrianglesVertexSize = 8
+
+type Matrix interface {
+	Element(i, j int) float64
+}
+
+// TextureQuads represents quads to draw textures.
+// Texture returns the texture coordinates normalized to [0, 1].
+type TextureQuads interface {
+	Len() int
+	Vertex(i int) (x0, y0, x1, y1 float32)
+	Texture(i int) (u0, v0, u1, v1 float32)
+}
+
+// A Mask represents a texture whose alpha values multiply the drawn colors.
+// The mask is aligned with the destination: the destination pixel (x, y) corresponds to
+// the texture pixel (x + Region.Min.X, y + Region.Min.Y). The alpha is 0 outside Region.
+// Width and Height are the internal size of the texture.
+type Mask struct {
+	Texture opengl.Texture
+	Region  image.Rectangle
+	Width   int
+	Height  int
+}
+
+type Lines interface {
+	Len() int
+	Points(i int) (x0, y0, x1, y1 float32)
+	Color(i int) color.Color
+}
+
+type Rects interface {
+	Len() int
+	Rect(i int) (x, y, width, height float32)
+	Color(i int) color.Color
+}
+
+// Uniforms represents the values of uniform variables for a user-defined program.
+// The length of a Floats' value is 1, 2, 3 or 4 for float or vectors and 9 or 16 for matrices (column-major).
+// Textures' values are bound to the texture units from 1 in the order of the names.
+type Uniforms struct {
+	Floats   map[string][]float32
+	Textures map[string]opengl.Texture
+}
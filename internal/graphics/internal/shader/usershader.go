@@ -0,0 +1,114 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !headless
+
+package shader
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hajimehoshi/ebiten/internal/opengl"
+	"sort"
+)
+
+// A Program is a program with a user-defined fragment shader.
+// The vertex shader is shaderVertexModelview.
+type Program struct {
+	native opengl.Program
+}
+
+func NewProgram(c *opengl.Context, fragmentShaderSource string) (Program, error) {
+	vertexShader, err := c.NewShader(c.VertexShader, shader(c, shaderVertexModelview))
+	if err != nil {
+		return Program{}, err
+	}
+	defer c.DeleteShader(vertexShader)
+
+	fragmentShader, err := c.NewShader(c.FragmentShader, adjustFragmentShader(fragmentShaderSource, c.GlslHighpSupported()))
+	if err != nil {
+		return Program{}, err
+	}
+	defer c.DeleteShader(fragmentShader)
+
+	p, err := c.NewProgram([]opengl.Shader{vertexShader, fragmentShader})
+	if err != nil {
+		return Program{}, err
+	}
+	return Program{p}, nil
+}
+
+func DeleteProgram(c *opengl.Context, p Program) {
+	if lastProgram.Equals(p.native) {
+		// Force to call UseProgram next time since the program ID might be reused.
+		var zero opengl.Program
+		lastProgram = zero
+	}
+	c.DeleteProgram(p.native)
+}
+
+func useUserProgram(c *opengl.Context, projectionMatrix []float32, p Program, texture opengl.Texture, geo Matrix, uniforms *Uniforms) programFinisher {
+	program := p.native
+	if !lastProgram.Equals(program) {
+		c.UseProgram(program)
+		lastProgram = program
+	}
+
+	c.BindElementArrayBuffer(indexBufferQuads)
+
+	c.UniformFloats(program, "projection_matrix", projectionMatrix)
+	c.UniformFloats(program, "modelview_matrix", glModelviewMatrix(geo))
+	c.UniformInt(program, "texture", 0)
+	c.BindTexture(texture)
+
+	for name, v := range uniforms.Floats {
+		c.UniformFloats(program, name, v)
+	}
+	names := []string{}
+	for name := range uniforms.Textures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		unit := i + 1
+		c.UniformInt(program, name, unit)
+		c.BindTextureUnit(uniforms.Textures[name], unit)
+	}
+
+	return enableTextureVertexAttribs(c, program)
+}
+
+// DrawTextureWithProgram draws the texture with the user-defined program.
+func DrawTextureWithProgram(c *opengl.Context, p Program, texture opengl.Texture, projectionMatrix *[4][4]float64, quads TextureQuads, geo Matrix, uniforms *Uniforms, mode opengl.CompositeMode) error {
+	if !initialized {
+		if err := initialize(c); err != nil {
+			return err
+		}
+		initialized = true
+	}
+
+	if quads.Len() == 0 {
+		return nil
+	}
+	c.BlendFunc(mode)
+	if QuadsMaxNum < quads.Len() {
+		return errors.New(fmt.Sprintf("len(quads) must be equal to or less than %d", QuadsMaxNum))
+	}
+
+	f := useUserProgram(c, glMatrix(projectionMatrix), p, texture, geo, uniforms)
+	defer f.FinishProgram()
+
+	drawQuads(c, quads)
+	return nil
+}
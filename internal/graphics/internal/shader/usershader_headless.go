@@ -0,0 +1,41 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build headless
+
+package shader
+
+import (
+	"errors"
+	"github.com/hajimehoshi/ebiten/internal/opengl"
+)
+
+// User-defined programs are not available in the headless mode
+// since the software rasterizer can't run GLSL.
+
+var errProgramNotSupported = errors.New("user-defined shaders are not supported in the headless mode")
+
+type Program struct{}
+
+func NewProgram(c *opengl.Context, fragmentShaderSource string) (Program, error) {
+	return Program{}, errProgramNotSupported
+}
+
+func DeleteProgram(c *opengl.Context, p Program) {
+	// Do nothing.
+}
+
+func DrawTextureWithProgram(c *opengl.Context, p Program, texture opengl.Texture, projectionMatrix *[4][4]float64, quads TextureQuads, geo Matrix, uniforms *Uniforms, mode opengl.CompositeMode) error {
+	return errProgramNotSupported
+}
@@ -0,0 +1,42 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphics
+
+import (
+	"github.com/hajimehoshi/ebiten/internal/graphics/internal/shader"
+	"github.com/hajimehoshi/ebiten/internal/opengl"
+)
+
+// A Shader is a user-defined fragment shader.
+type Shader struct {
+	program shader.Program
+}
+
+func NewShader(c *opengl.Context, source string) (*Shader, error) {
+	p, err := shader.NewProgram(c, source)
+	if err != nil {
+		return nil, err
+	}
+	return &Shader{p}, nil
+}
+
+func (s *Shader) Dispose(c *opengl.Context) error {
+	// The deferred commands might use this shader.
+	if err := FlushCommands(c); err != nil {
+		return err
+	}
+	shader.DeleteProgram(c, s.program)
+	return nil
+}
@@ -22,17 +22,19 @@ import (
 	"image/draw"
 )
 
-func adjustImageForTexture(img image.Image) *image.RGBA {
-	width, height := img.Bounds().Size().X, img.Bounds().Size().Y
-	adjustedImageBounds := image.Rectangle{
-		image.ZP,
-		image.Point{
-			internal.NextPowerOf2Int(width),
-			internal.NextPowerOf2Int(height),
-		},
+// internalSize returns the actual size of a texture or a framebuffer for the given size.
+// Textures are padded to powers of 2 only when the context doesn't support other sizes.
+func internalSize(c *opengl.Context, x int) int {
+	if c.NPOTSupported() {
+		return x
 	}
-	if nrgba, ok := img.(*image.RGBA); ok && img.Bounds() == adjustedImageBounds {
-		return nrgba
+	return internal.NextPowerOf2Int(x)
+}
+
+func adjustImageForTexture(img image.Image, width, height int) *image.RGBA {
+	adjustedImageBounds := image.Rect(0, 0, width, height)
+	if rgba, ok := img.(*image.RGBA); ok && img.Bounds() == adjustedImageBounds {
+		return rgba
 	}
 
 	adjustedImage := image.NewRGBA(adjustedImageBounds)
@@ -40,53 +42,67 @@ func adjustImageForTexture(img image.Image) *image.RGBA {
 		image.ZP,
 		img.Bounds().Size(),
 	}
-	draw.Draw(adjustedImage, dstBounds, img, image.ZP, draw.Src)
+	draw.Draw(adjustedImage, dstBounds, img, img.Bounds().Min, draw.Src)
 	return adjustedImage
 }
 
 type Texture struct {
-	native opengl.Texture
-	width  int
-	height int
+	native         opengl.Texture
+	width          int
+	height         int
+	internalWidth  int
+	internalHeight int
 }
 
 func (t *Texture) Size() (width, height int) {
 	return t.width, t.height
 }
 
+// InternalSize returns the actual size of the texture, which might be bigger than Size.
+func (t *Texture) InternalSize() (width, height int) {
+	return t.internalWidth, t.internalHeight
+}
+
 func NewTexture(c *opengl.Context, width, height int, filter opengl.Filter) (*Texture, error) {
-	w := internal.NextPowerOf2Int(width)
-	h := internal.NextPowerOf2Int(height)
-	if w < 4 {
-		return nil, errors.New("width must be equal or more than 4.")
-	}
-	if h < 4 {
-		return nil, errors.New("height must be equal or more than 4.")
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("width and height must be positive")
 	}
+	w, h := internalSize(c, width), internalSize(c, height)
 	native, err := c.NewTexture(w, h, nil, filter)
 	if err != nil {
 		return nil, err
 	}
-	return &Texture{native, width, height}, nil
+	return &Texture{native, width, height, w, h}, nil
 }
 
 func NewTextureFromImage(c *opengl.Context, img image.Image, filter opengl.Filter) (*Texture, error) {
-	origSize := img.Bounds().Size()
-	if origSize.X < 4 {
-		return nil, errors.New("width must be equal or more than 4.")
+	size := img.Bounds().Size()
+	if size.X <= 0 || size.Y <= 0 {
+		return nil, errors.New("width and height must be positive")
 	}
-	if origSize.Y < 4 {
-		return nil, errors.New("height must be equal or more than 4.")
-	}
-	adjustedImage := adjustImageForTexture(img)
-	size := adjustedImage.Bounds().Size()
-	native, err := c.NewTexture(size.X, size.Y, adjustedImage.Pix, filter)
+	w, h := internalSize(c, size.X), internalSize(c, size.Y)
+	adjustedImage := adjustImageForTexture(img, w, h)
+	native, err := c.NewTexture(w, h, adjustedImage.Pix, filter)
 	if err != nil {
 		return nil, err
 	}
-	return &Texture{native, origSize.X, origSize.Y}, nil
+	return &Texture{native, size.X, size.Y, w, h}, nil
 }
 
-func (t *Texture) Dispose(c *opengl.Context) {
+// ReplacePixels replaces the pixels in the rectangle (x, y, width, height) with the alpha-premultiplied RGBA pixels.
+func (t *Texture) ReplacePixels(c *opengl.Context, pixels []uint8, x, y, width, height int) error {
+	// The deferred commands might read or write this texture.
+	if err := FlushCommands(c); err != nil {
+		return err
+	}
+	c.TexSubImage2D(t.native, x, y, width, height, pixels)
+	return nil
+}
+
+func (t *Texture) Dispose(c *opengl.Context) error {
+	if err := FlushCommands(c); err != nil {
+		return err
+	}
 	c.DeleteTexture(t.native)
+	return nil
 }
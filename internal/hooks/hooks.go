@@ -0,0 +1,69 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hooks provides the functions called from the game loop.
+// This package doesn't depend on any platforms
+// so that other packages like audio can use this without a window.
+package hooks
+
+import (
+	"sync"
+)
+
+// A Hook is a handle of a function appended by AppendHookOnUpdate.
+type Hook struct {
+	f func() error
+}
+
+var (
+	onUpdateHooks []*Hook
+	m             sync.Mutex
+)
+
+// AppendHookOnUpdate appends a function that is called at every frame after the game is updated.
+// The returned hook can be passed to RemoveHookOnUpdate.
+func AppendHookOnUpdate(f func() error) *Hook {
+	m.Lock()
+	defer m.Unlock()
+	h := &Hook{f}
+	onUpdateHooks = append(onUpdateHooks, h)
+	return h
+}
+
+// RemoveHookOnUpdate removes the hook so that it is no longer called. Removing a hook twice does nothing.
+func RemoveHookOnUpdate(h *Hook) {
+	m.Lock()
+	defer m.Unlock()
+	// Make a new slice since Run might be iterating the current one.
+	hooks := make([]*Hook, 0, len(onUpdateHooks))
+	for _, hook := range onUpdateHooks {
+		if hook != h {
+			hooks = append(hooks, hook)
+		}
+	}
+	onUpdateHooks = hooks
+}
+
+// Run calls the hooks in order.
+func Run() error {
+	m.Lock()
+	hooks := onUpdateHooks
+	m.Unlock()
+	for _, h := range hooks {
+		if err := h.f(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hooks
+
+import (
+	"testing"
+)
+
+func TestRemoveHookOnUpdate(t *testing.T) {
+	defer func() {
+		onUpdateHooks = nil
+	}()
+	calls := []int{}
+	hooks := []*Hook{}
+	for i := 0; i < 3; i++ {
+		i := i
+		hooks = append(hooks, AppendHookOnUpdate(func() error {
+			calls = append(calls, i)
+			return nil
+		}))
+	}
+	RemoveHookOnUpdate(hooks[1])
+	// Removing twice does nothing.
+	RemoveHookOnUpdate(hooks[1])
+	if err := Run(); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 2 || calls[0] != 0 || calls[1] != 2 {
+		t.Errorf("calls: got %v; want [0 2]", calls)
+	}
+}
+
+func TestRemoveHookOnUpdateWhileRunning(t *testing.T) {
+	defer func() {
+		onUpdateHooks = nil
+	}()
+	calls := 0
+	var h1 *Hook
+	AppendHookOnUpdate(func() error {
+		// The removal takes effect from the next Run.
+		RemoveHookOnUpdate(h1)
+		return nil
+	})
+	h1 = AppendHookOnUpdate(func() error {
+		calls++
+		return nil
+	})
+	for i := 0; i < 2; i++ {
+		if err := Run(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("calls: got %d; want 1", calls)
+	}
+}
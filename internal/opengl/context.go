@@ -12,7 +12,7 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// +build !js
+// +build !js,!headless
 
 package opengl
 
@@ -20,6 +20,8 @@ import (
 	"errors"
 	"fmt"
 	"github.com/go-gl/gl"
+	"strconv"
+	"strings"
 )
 
 type Texture gl.Texture
@@ -42,7 +44,10 @@ func GetProgramID(p Program) ProgramID {
 	return ProgramID(p)
 }
 
-type context struct{}
+type context struct {
+	npotSupported     bool
+	lastCompositeMode CompositeMode
+}
 
 func NewContext() *Context {
 	c := &Context{
@@ -65,7 +70,63 @@ func (c *Context) init() {
 	gl.Init()
 	// Textures' pixel formats are alpha premultiplied.
 	gl.Enable(gl.BLEND)
+	c.lastCompositeMode = CompositeModeSourceOver
 	gl.BlendFunc(gl.ONE, gl.ONE_MINUS_SRC_ALPHA)
+	// The scissor box is always specified with the viewport.
+	gl.Enable(gl.SCISSOR_TEST)
+	c.npotSupported = npotSupported()
+}
+
+// npotSupported returns a boolean indicating whether textures of non-power-of-two sizes are available.
+// They are in the core since OpenGL 2.0.
+func npotSupported() bool {
+	version := gl.GetString(gl.VERSION)
+	if i := strings.Index(version, "."); 0 < i {
+		if major, err := strconv.Atoi(version[:i]); err == nil && 2 <= major {
+			return true
+		}
+	}
+	for _, e := range strings.Split(gl.GetString(gl.EXTENSIONS), " ") {
+		if e == "GL_ARB_texture_non_power_of_two" {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Context) NPOTSupported() bool {
+	return c.npotSupported
+}
+
+func glOperation(o operation) gl.GLenum {
+	switch o {
+	case zero:
+		return gl.ZERO
+	case one:
+		return gl.ONE
+	case srcAlpha:
+		return gl.SRC_ALPHA
+	case dstAlpha:
+		return gl.DST_ALPHA
+	case oneMinusSrcAlpha:
+		return gl.ONE_MINUS_SRC_ALPHA
+	case oneMinusDstAlpha:
+		return gl.ONE_MINUS_DST_ALPHA
+	case dstColor:
+		return gl.DST_COLOR
+	case oneMinusSrcColor:
+		return gl.ONE_MINUS_SRC_COLOR
+	}
+	panic("not reach")
+}
+
+func (c *Context) BlendFunc(mode CompositeMode) {
+	if c.lastCompositeMode == mode {
+		return
+	}
+	c.lastCompositeMode = mode
+	s, d := operations(mode)
+	gl.BlendFunc(glOperation(s), glOperation(d))
 }
 
 func (c *Context) NewTexture(width, height int, pixels []uint8, filter Filter) (Texture, error) {
@@ -78,12 +139,22 @@ func (c *Context) NewTexture(width, height int, pixels []uint8, filter Filter) (
 
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, int(filter))
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, int(filter))
+	// CLAMP_TO_EDGE is used on all the platforms instead of GL's default REPEAT
+	// since WebGL requires it for non-power-of-two textures. See also the doc of DrawImage.
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
 
 	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, width, height, 0, gl.RGBA, gl.UNSIGNED_BYTE, pixels)
 
 	return Texture(t), nil
 }
 
+func (c *Context) TexSubImage2D(t Texture, x, y, width, height int, pixels []uint8) {
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 4)
+	gl.Texture(t).Bind(gl.TEXTURE_2D)
+	gl.TexSubImage2D(gl.TEXTURE_2D, 0, x, y, width, height, gl.RGBA, gl.UNSIGNED_BYTE, pixels)
+}
+
 func (c *Context) FramebufferPixels(f Framebuffer, width, height int) ([]uint8, error) {
 	gl.Flush()
 
@@ -101,6 +172,14 @@ func (c *Context) BindTexture(t Texture) {
 	gl.Texture(t).Bind(gl.TEXTURE_2D)
 }
 
+// BindTextureUnit binds the texture to the texture unit.
+// The active texture unit is restored to 0.
+func (c *Context) BindTextureUnit(t Texture, unit int) {
+	gl.ActiveTexture(gl.TEXTURE0 + gl.GLenum(unit))
+	gl.Texture(t).Bind(gl.TEXTURE_2D)
+	gl.ActiveTexture(gl.TEXTURE0)
+}
+
 func (c *Context) DeleteTexture(t Texture) {
 	gl.Texture(t).Delete()
 }
@@ -131,6 +210,10 @@ func (c *Context) SetViewport(f Framebuffer, width, height int) error {
 	return nil
 }
 
+func (c *Context) SetScissor(x, y, width, height int) {
+	gl.Scissor(x, y, width, height)
+}
+
 func (c *Context) FillFramebuffer(r, g, b, a float64) error {
 	gl.ClearColor(gl.GLclampf(r), gl.GLclampf(g), gl.GLclampf(b), gl.GLclampf(a))
 	gl.Clear(gl.COLOR_BUFFER_BIT)
@@ -180,11 +263,16 @@ func (c *Context) NewProgram(shaders []Shader) (Program, error) {
 	}
 	p.Link()
 	if p.Get(gl.LINK_STATUS) == gl.FALSE {
-		return 0, errors.New("program error")
+		return 0, errors.New(fmt.Sprintf("program error: %s", p.GetInfoLog()))
 	}
 	return Program(p), nil
 }
 
+func (c *Context) DeleteProgram(p Program) {
+	deleteLocationCache(p)
+	gl.Program(p).Delete()
+}
+
 func (c *Context) UseProgram(p Program) {
 	gl.Program(p).Use()
 }
@@ -201,8 +289,18 @@ func (c *Context) UniformInt(p Program, location string, v int) {
 func (c *Context) UniformFloats(p Program, location string, v []float32) {
 	l := gl.UniformLocation(GetUniformLocation(c, p, location))
 	switch len(v) {
+	case 1:
+		l.Uniform1fv(1, v)
+	case 2:
+		l.Uniform2fv(1, v)
+	case 3:
+		l.Uniform3fv(1, v)
 	case 4:
 		l.Uniform4fv(1, v)
+	case 9:
+		v2 := [9]float32{}
+		copy(v2[:], v)
+		l.UniformMatrix3fv(false, v2)
 	case 16:
 		v2 := [16]float32{}
 		copy(v2[:], v)
@@ -216,13 +314,9 @@ func (c *Context) GetAttribLocation(p Program, location string) AttribLocation {
 	return AttribLocation(gl.Program(p).GetAttribLocation(location))
 }
 
-func (c *Context) VertexAttribPointer(p Program, location string, signed bool, normalize bool, stride int, size int, v uintptr) {
+func (c *Context) VertexAttribPointer(p Program, location string, stride int, size int, v uintptr) {
 	l := gl.AttribLocation(GetAttribLocation(c, p, location))
-	t := gl.GLenum(gl.SHORT)
-	if !signed {
-		t = gl.UNSIGNED_SHORT
-	}
-	l.AttribPointer(uint(size), t, normalize, stride, v)
+	l.AttribPointer(uint(size), gl.FLOAT, false, stride, v)
 }
 
 func (c *Context) EnableVertexAttribArray(p Program, location string) {
@@ -259,9 +353,19 @@ func (c *Context) BindElementArrayBuffer(b Buffer) {
 	gl.Buffer(b).Bind(gl.ELEMENT_ARRAY_BUFFER)
 }
 
-func (c *Context) BufferSubData(bufferType BufferType, data []int16) {
-	const int16Size = 2
-	gl.BufferSubData(gl.GLenum(bufferType), 0, int16Size*len(data), data)
+// BufferSubData replaces the data of the buffer from the beginning.
+// data is []uint16 or []float32.
+func (c *Context) BufferSubData(bufferType BufferType, data interface{}) {
+	size := 0
+	switch data := data.(type) {
+	case []uint16:
+		size = 2 * len(data)
+	case []float32:
+		size = 4 * len(data)
+	default:
+		panic("not reach")
+	}
+	gl.BufferSubData(gl.GLenum(bufferType), 0, size, data)
 }
 
 func (c *Context) DrawElements(mode Mode, len int) {
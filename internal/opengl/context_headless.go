@@ -0,0 +1,280 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build headless
+
+package opengl
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// In the headless mode, textures and framebuffers are kept in the main memory
+// and rendering is done by the software rasterizer in the shader package.
+// The results should be the same as the ones with OpenGL:
+// pixels are alpha-premultiplied and blended with the factors of the current composite mode.
+
+type surface struct {
+	width  int
+	height int
+	pixels []uint8
+	filter Filter
+}
+
+type Texture struct {
+	*surface
+}
+
+type Framebuffer struct {
+	*surface
+}
+
+type context struct {
+	screen         *surface
+	framebuffer    *surface
+	viewportWidth  int
+	viewportHeight int
+	scissor        scissor
+	compositeMode  CompositeMode
+}
+
+type scissor struct {
+	x, y, width, height int
+}
+
+func (s *scissor) contains(x, y int) bool {
+	return s.x <= x && x < s.x+s.width && s.y <= y && y < s.y+s.height
+}
+
+func NewContext() *Context {
+	c := &Context{
+		Nearest:   1,
+		Linear:    2,
+		Triangles: 1,
+		Lines:     2,
+	}
+	c.screen = &surface{}
+	return c
+}
+
+func (c *Context) NPOTSupported() bool {
+	return true
+}
+
+func (c *Context) BlendFunc(mode CompositeMode) {
+	c.compositeMode = mode
+}
+
+func (c *Context) NewTexture(width, height int, pixels []uint8, filter Filter) (Texture, error) {
+	if width <= 0 || height <= 0 {
+		return Texture{nil}, errors.New("texture size must be positive")
+	}
+	s := &surface{
+		width:  width,
+		height: height,
+		pixels: make([]uint8, 4*width*height),
+		filter: filter,
+	}
+	if pixels != nil {
+		copy(s.pixels, pixels)
+	}
+	return Texture{s}, nil
+}
+
+func (c *Context) TexSubImage2D(t Texture, x, y, width, height int, pixels []uint8) {
+	s := t.surface
+	for j := 0; j < height; j++ {
+		copy(s.pixels[4*(x+(y+j)*s.width):], pixels[4*j*width:4*(j+1)*width])
+	}
+}
+
+func (c *Context) FramebufferPixels(f Framebuffer, width, height int) ([]uint8, error) {
+	s := f.surface
+	if s == nil {
+		s = c.screen
+	}
+	if s.width != width || s.height != height {
+		return nil, errors.New(fmt.Sprintf("framebuffer size mismatch: (%d, %d) vs (%d, %d)", s.width, s.height, width, height))
+	}
+	pixels := make([]uint8, len(s.pixels))
+	copy(pixels, s.pixels)
+	return pixels, nil
+}
+
+func (c *Context) DeleteTexture(t Texture) {
+	t.pixels = nil
+}
+
+func (c *Context) NewFramebuffer(texture Texture) (Framebuffer, error) {
+	if texture.surface == nil {
+		return Framebuffer{nil}, errors.New("creating framebuffer failed")
+	}
+	return Framebuffer{texture.surface}, nil
+}
+
+func (c *Context) SetViewport(f Framebuffer, width, height int) error {
+	s := f.surface
+	if s == nil {
+		// The default framebuffer is allocated lazily since its size is not known beforehand.
+		s = c.screen
+		if s.width != width || s.height != height {
+			s.width = width
+			s.height = height
+			s.pixels = make([]uint8, 4*width*height)
+		}
+	}
+	if s.pixels == nil {
+		return errors.New("the framebuffer is already disposed")
+	}
+	c.framebuffer = s
+	c.viewportWidth = width
+	c.viewportHeight = height
+	c.scissor = scissor{0, 0, width, height}
+	return nil
+}
+
+func (c *Context) SetScissor(x, y, width, height int) {
+	c.scissor = scissor{x, y, width, height}
+}
+
+func (c *Context) FillFramebuffer(r, g, b, a float64) error {
+	s := c.framebuffer
+	if s == nil {
+		return errors.New("no framebuffer is bound")
+	}
+	cr, cg, cb, ca := toUint8(r), toUint8(g), toUint8(b), toUint8(a)
+	for j := 0; j < s.height; j++ {
+		for i := 0; i < s.width; i++ {
+			if !c.scissor.contains(i, j) {
+				continue
+			}
+			k := 4 * (i + j*s.width)
+			s.pixels[k] = cr
+			s.pixels[k+1] = cg
+			s.pixels[k+2] = cb
+			s.pixels[k+3] = ca
+		}
+	}
+	return nil
+}
+
+func (c *Context) DeleteFramebuffer(f Framebuffer) {
+	// Do nothing: the pixels belong to the texture.
+}
+
+func (c *Context) Flush() {
+	// Do nothing.
+}
+
+// ViewportSize returns the size of the current viewport.
+func (c *Context) ViewportSize() (width, height int) {
+	return c.viewportWidth, c.viewportHeight
+}
+
+// TextureColor returns the alpha-premultiplied color of the texture at the normalized coordinate (u, v).
+// The texture is sampled with its filter and clamped as GL_CLAMP_TO_EDGE does.
+func (c *Context) TextureColor(t Texture, u, v float64) (r, g, b, a float64) {
+	s := t.surface
+	x := u * float64(s.width)
+	y := v * float64(s.height)
+	if s.filter != c.Linear {
+		return s.at(int(math.Floor(x)), int(math.Floor(y)))
+	}
+	x -= 0.5
+	y -= 0.5
+	x0, y0 := math.Floor(x), math.Floor(y)
+	fx, fy := x-x0, y-y0
+	ix, iy := int(x0), int(y0)
+	r00, g00, b00, a00 := s.at(ix, iy)
+	r10, g10, b10, a10 := s.at(ix+1, iy)
+	r01, g01, b01, a01 := s.at(ix, iy+1)
+	r11, g11, b11, a11 := s.at(ix+1, iy+1)
+	lerp := func(v00, v10, v01, v11 float64) float64 {
+		return (v00*(1-fx)+v10*fx)*(1-fy) + (v01*(1-fx)+v11*fx)*fy
+	}
+	return lerp(r00, r10, r01, r11), lerp(g00, g10, g01, g11), lerp(b00, b10, b01, b11), lerp(a00, a10, a01, a11)
+}
+
+// factor returns the blend factor of the operation for the i-th component.
+// As OpenGL does, color factors for the alpha component are alpha values.
+func factor(o operation, src, dst *[4]float64, i int) float64 {
+	switch o {
+	case zero:
+		return 0
+	case one:
+		return 1
+	case srcAlpha:
+		return src[3]
+	case dstAlpha:
+		return dst[3]
+	case oneMinusSrcAlpha:
+		return 1 - src[3]
+	case oneMinusDstAlpha:
+		return 1 - dst[3]
+	case dstColor:
+		return dst[i]
+	case oneMinusSrcColor:
+		return 1 - src[i]
+	}
+	panic("not reach")
+}
+
+// BlendColor composites the alpha-premultiplied color onto the current framebuffer at (x, y)
+// in the viewport coordinate.
+func (c *Context) BlendColor(x, y int, r, g, b, a float64) {
+	s := c.framebuffer
+	if x < 0 || y < 0 || s.width <= x || s.height <= y || !c.scissor.contains(x, y) {
+		return
+	}
+	i := 4 * (x + y*s.width)
+	p := s.pixels[i : i+4]
+	const max = math.MaxUint8
+	src := [4]float64{r, g, b, a}
+	dst := [4]float64{float64(p[0]) / max, float64(p[1]) / max, float64(p[2]) / max, float64(p[3]) / max}
+	so, do := operations(c.compositeMode)
+	for k := range p {
+		p[k] = toUint8(src[k]*factor(so, &src, &dst, k) + dst[k]*factor(do, &src, &dst, k))
+	}
+}
+
+func (s *surface) at(x, y int) (r, g, b, a float64) {
+	if x < 0 {
+		x = 0
+	}
+	if s.width <= x {
+		x = s.width - 1
+	}
+	if y < 0 {
+		y = 0
+	}
+	if s.height <= y {
+		y = s.height - 1
+	}
+	i := 4 * (x + y*s.width)
+	p := s.pixels[i : i+4]
+	const max = math.MaxUint8
+	return float64(p[0]) / max, float64(p[1]) / max, float64(p[2]) / max, float64(p[3]) / max
+}
+
+func toUint8(v float64) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if 1 <= v {
+		return math.MaxUint8
+	}
+	return uint8(math.Floor(v*math.MaxUint8 + 0.5))
+}
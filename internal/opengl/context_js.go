@@ -61,7 +61,8 @@ func GetProgramID(p Program) ProgramID {
 }
 
 type context struct {
-	gl *webgl.Context
+	gl                *webgl.Context
+	lastCompositeMode CompositeMode
 }
 
 var lastFramebuffer Framebuffer
@@ -88,7 +89,48 @@ func (c *Context) init() {
 	gl := c.gl
 	// Textures' pixel formats are alpha premultiplied.
 	gl.Enable(gl.BLEND)
+	c.lastCompositeMode = CompositeModeSourceOver
 	gl.BlendFunc(gl.ONE, gl.ONE_MINUS_SRC_ALPHA)
+	// The scissor box is always specified with the viewport.
+	gl.Enable(gl.SCISSOR_TEST)
+}
+
+func (c *Context) glOperation(o operation) int {
+	gl := c.gl
+	switch o {
+	case zero:
+		return gl.ZERO
+	case one:
+		return gl.ONE
+	case srcAlpha:
+		return gl.SRC_ALPHA
+	case dstAlpha:
+		return gl.DST_ALPHA
+	case oneMinusSrcAlpha:
+		return gl.ONE_MINUS_SRC_ALPHA
+	case oneMinusDstAlpha:
+		return gl.ONE_MINUS_DST_ALPHA
+	case dstColor:
+		return gl.DST_COLOR
+	case oneMinusSrcColor:
+		return gl.ONE_MINUS_SRC_COLOR
+	}
+	panic("not reach")
+}
+
+func (c *Context) BlendFunc(mode CompositeMode) {
+	if c.lastCompositeMode == mode {
+		return
+	}
+	c.lastCompositeMode = mode
+	s, d := operations(mode)
+	c.gl.BlendFunc(c.glOperation(s), c.glOperation(d))
+}
+
+// NPOTSupported returns true: WebGL allows textures of non-power-of-two sizes
+// as long as they are not mipmapped and their wrap mode is CLAMP_TO_EDGE.
+func (c *Context) NPOTSupported() bool {
+	return true
 }
 
 func (c *Context) NewTexture(width, height int, pixels []uint8, filter Filter) (Texture, error) {
@@ -102,6 +144,8 @@ func (c *Context) NewTexture(width, height int, pixels []uint8, filter Filter) (
 
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, int(filter))
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, int(filter))
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
 
 	// void texImage2D(GLenum target, GLint level, GLenum internalformat,
 	//     GLsizei width, GLsizei height, GLint border, GLenum format,
@@ -115,6 +159,16 @@ func (c *Context) NewTexture(width, height int, pixels []uint8, filter Filter) (
 	return Texture{t}, nil
 }
 
+func (c *Context) TexSubImage2D(t Texture, x, y, width, height int, pixels []uint8) {
+	gl := c.gl
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 4)
+	gl.BindTexture(gl.TEXTURE_2D, t.Object)
+	// void texSubImage2D(GLenum target, GLint level, GLint xoffset, GLint yoffset,
+	//     GLsizei width, GLsizei height,
+	//     GLenum format, GLenum type, ArrayBufferView? pixels);
+	gl.Call("texSubImage2D", gl.TEXTURE_2D, 0, x, y, width, height, gl.RGBA, gl.UNSIGNED_BYTE, pixels)
+}
+
 func (c *Context) FramebufferPixels(f Framebuffer, width, height int) ([]uint8, error) {
 	gl := c.gl
 	gl.Flush()
@@ -135,6 +189,15 @@ func (c *Context) BindTexture(t Texture) {
 	gl.BindTexture(gl.TEXTURE_2D, t.Object)
 }
 
+// BindTextureUnit binds the texture to the texture unit.
+// The active texture unit is restored to 0.
+func (c *Context) BindTextureUnit(t Texture, unit int) {
+	gl := c.gl
+	gl.ActiveTexture(gl.TEXTURE0 + unit)
+	gl.BindTexture(gl.TEXTURE_2D, t.Object)
+	gl.ActiveTexture(gl.TEXTURE0)
+}
+
 func (c *Context) DeleteTexture(t Texture) {
 	gl := c.gl
 	gl.DeleteTexture(t.Object)
@@ -180,6 +243,10 @@ func (c *Context) SetViewport(f Framebuffer, width, height int) error {
 	return nil
 }
 
+func (c *Context) SetScissor(x, y, width, height int) {
+	c.gl.Scissor(x, y, width, height)
+}
+
 func (c *Context) FillFramebuffer(r, g, b, a float64) error {
 	// TODO: Use f?
 	gl := c.gl
@@ -232,11 +299,18 @@ func (c *Context) NewProgram(shaders []Shader) (Program, error) {
 	}
 	gl.LinkProgram(p)
 	if !gl.GetProgramParameterb(p, gl.LINK_STATUS) {
-		return Program{nil}, errors.New("program error")
+		log := gl.Call("getProgramInfoLog", p).String()
+		return Program{nil}, errors.New(fmt.Sprintf("program error: %s", log))
 	}
 	return Program{p}, nil
 }
 
+func (c *Context) DeleteProgram(p Program) {
+	gl := c.gl
+	deleteLocationCache(p)
+	gl.DeleteProgram(p.Object)
+}
+
 func (c *Context) UseProgram(p Program) {
 	gl := c.gl
 	gl.UseProgram(p.Object)
@@ -257,8 +331,16 @@ func (c *Context) UniformFloats(p Program, location string, v []float32) {
 	gl := c.gl
 	l := GetUniformLocation(c, p, location)
 	switch len(v) {
+	case 1:
+		gl.Call("uniform1fv", l.Object, v)
+	case 2:
+		gl.Call("uniform2fv", l.Object, v)
+	case 3:
+		gl.Call("uniform3fv", l.Object, v)
 	case 4:
 		gl.Call("uniform4fv", l.Object, v)
+	case 9:
+		gl.Call("uniformMatrix3fv", l.Object, false, v)
 	case 16:
 		gl.UniformMatrix4fv(l.Object, false, v)
 	default:
@@ -271,14 +353,10 @@ func (c *Context) GetAttribLocation(p Program, location string) AttribLocation {
 	return AttribLocation(gl.GetAttribLocation(p.Object, location))
 }
 
-func (c *Context) VertexAttribPointer(p Program, location string, signed bool, normalize bool, stride int, size int, v uintptr) {
+func (c *Context) VertexAttribPointer(p Program, location string, stride int, size int, v uintptr) {
 	gl := c.gl
 	l := GetAttribLocation(c, p, location)
-	t := gl.SHORT
-	if !signed {
-		t = gl.UNSIGNED_SHORT
-	}
-	gl.VertexAttribPointer(int(l), size, t, normalize, stride, int(v))
+	gl.VertexAttribPointer(int(l), size, gl.FLOAT, false, stride, int(v))
 }
 
 func (c *Context) EnableVertexAttribArray(p Program, location string) {
@@ -306,7 +384,9 @@ func (c *Context) BindElementArrayBuffer(b Buffer) {
 	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, b.Object)
 }
 
-func (c *Context) BufferSubData(bufferType BufferType, data []int16) {
+// BufferSubData replaces the data of the buffer from the beginning.
+// data is []uint16 or []float32.
+func (c *Context) BufferSubData(bufferType BufferType, data interface{}) {
 	gl := c.gl
 	gl.BufferSubData(int(bufferType), 0, data)
 }
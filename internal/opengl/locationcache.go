@@ -12,6 +12,8 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// +build !headless
+
 package opengl
 
 // Since js.Object (Program) can't be keys of a map, use integers (ProgramID) instead.
@@ -52,3 +54,11 @@ func GetAttribLocation(g AttribLocationGetter, p Program, location string) Attri
 	}
 	return l
 }
+
+// deleteLocationCache removes the cache of the program
+// since the program ID might be reused after the program is deleted.
+func deleteLocationCache(p Program) {
+	id := GetProgramID(p)
+	delete(uniformLocationCache, id)
+	delete(attribLocationCache, id)
+}
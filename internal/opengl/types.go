@@ -20,6 +20,52 @@ type BufferType int
 type BufferUsage int
 type Mode int
 
+// CompositeMode represents how the source colors are blended with the destination colors.
+// All the colors are alpha-premultiplied.
+type CompositeMode int
+
+const (
+	CompositeModeSourceOver CompositeMode = iota
+	CompositeModeCopy
+	CompositeModeDestinationOut
+	CompositeModeLighter
+	CompositeModeMultiply
+	CompositeModeScreen
+)
+
+// operation represents a blend factor (e.g. GL_ONE).
+type operation int
+
+const (
+	zero operation = iota
+	one
+	srcAlpha
+	dstAlpha
+	oneMinusSrcAlpha
+	oneMinusDstAlpha
+	dstColor
+	oneMinusSrcColor
+)
+
+// operations returns the blend factors for the source and the destination.
+func operations(mode CompositeMode) (src operation, dst operation) {
+	switch mode {
+	case CompositeModeSourceOver:
+		return one, oneMinusSrcAlpha
+	case CompositeModeCopy:
+		return one, zero
+	case CompositeModeDestinationOut:
+		return zero, oneMinusSrcAlpha
+	case CompositeModeLighter:
+		return one, one
+	case CompositeModeMultiply:
+		return dstColor, oneMinusSrcAlpha
+	case CompositeModeScreen:
+		return one, oneMinusSrcColor
+	}
+	panic("not reach")
+}
+
 type Context struct {
 	Nearest            Filter
 	Linear             Filter
@@ -14,10 +14,24 @@
 
 package ui
 
+import (
+	"errors"
+	"fmt"
+	"unicode"
+)
+
 func IsKeyPressed(key Key) bool {
 	return currentInput.keyPressed[key]
 }
 
+func KeyPressDuration(key Key) int {
+	return currentDurations.key[key]
+}
+
+func IsKeyJustReleased(key Key) bool {
+	return currentDurations.keyReleased[key]
+}
+
 func CursorPosition() (x, y int) {
 	return currentInput.cursorX, currentInput.cursorY
 }
@@ -26,6 +40,14 @@ func IsMouseButtonPressed(button MouseButton) bool {
 	return currentInput.mouseButtonPressed[button]
 }
 
+func MouseButtonPressDuration(button MouseButton) int {
+	return currentDurations.mouseButton[button]
+}
+
+func IsMouseButtonJustReleased(button MouseButton) bool {
+	return currentDurations.mouseButtonReleased[button]
+}
+
 func GamepadAxisNum(id int) int {
 	if len(currentInput.gamepads) <= id {
 		return 0
@@ -54,6 +76,139 @@ func IsGamepadButtonPressed(id int, button GamepadButton) bool {
 	return currentInput.gamepads[id].buttonPressed[button]
 }
 
+func GamepadButtonPressDuration(id int, button GamepadButton) int {
+	if len(currentDurations.gamepads) <= id {
+		return 0
+	}
+	return currentDurations.gamepads[id].button[button]
+}
+
+func IsGamepadButtonJustReleased(id int, button GamepadButton) bool {
+	if len(currentDurations.gamepads) <= id {
+		return false
+	}
+	return currentDurations.gamepads[id].buttonReleased[button]
+}
+
+func InputChars() []rune {
+	return currentEvents.chars
+}
+
+func Wheel() (x, y float64) {
+	return currentEvents.wheelX, currentEvents.wheelY
+}
+
+func CursorMovement() (x, y float64) {
+	return currentEvents.movementX, currentEvents.movementY
+}
+
+func IsCursorInWindow() bool {
+	return currentInput.cursorInWindow
+}
+
+// CursorMode represents how the cursor is shown.
+type CursorMode int
+
+const (
+	CursorModeVisible CursorMode = iota
+	CursorModeHidden
+	CursorModeCaptured
+)
+
+// UpdateInputFrame counts the frames while the keys and the buttons are pressed,
+// and takes the events since the last frame.
+// This must be called once at every frame after the input state is updated.
+func UpdateInputFrame() {
+	currentDurations.update(&currentInput)
+	currentEvents = pendingEvents
+	pendingEvents = frameEvents{}
+}
+
+func ResetInputFrame() {
+	currentDurations = inputDurations{}
+	currentEvents = frameEvents{}
+	pendingEvents = frameEvents{}
+}
+
+// The functions below overwrite the input state to feed scripted input instead of the actual devices.
+
+func ResetInput() {
+	currentInput = input{}
+}
+
+func SetKeyPressed(key Key, pressed bool) error {
+	if key < 0 || len(currentInput.keyPressed) <= int(key) {
+		return errors.New(fmt.Sprintf("ui: invalid key: %d", key))
+	}
+	currentInput.keyPressed[key] = pressed
+	return nil
+}
+
+func SetMouseButtonPressed(button MouseButton, pressed bool) error {
+	if button < 0 || len(currentInput.mouseButtonPressed) <= int(button) {
+		return errors.New(fmt.Sprintf("ui: invalid mouse button: %d", button))
+	}
+	currentInput.mouseButtonPressed[button] = pressed
+	return nil
+}
+
+func SetCursorPosition(x, y int) {
+	currentInput.cursorX, currentInput.cursorY = x, y
+}
+
+func AddInputChars(chars []rune) {
+	for _, r := range chars {
+		addInputChar(r)
+	}
+}
+
+func AddWheel(x, y float64) {
+	pendingEvents.wheelX += x
+	pendingEvents.wheelY += y
+}
+
+func AddCursorMovement(x, y float64) {
+	pendingEvents.movementX += x
+	pendingEvents.movementY += y
+}
+
+func SetCursorInWindow(in bool) {
+	currentInput.cursorInWindow = in
+}
+
+func SetGamepadAxis(id int, axis int, value float64) error {
+	if id < 0 || len(currentInput.gamepads) <= id {
+		return errors.New(fmt.Sprintf("ui: invalid gamepad ID: %d", id))
+	}
+	g := &currentInput.gamepads[id]
+	if axis < 0 || len(g.axes) <= axis {
+		return errors.New(fmt.Sprintf("ui: invalid gamepad axis: %d", axis))
+	}
+	if g.axisNum <= axis {
+		g.axisNum = axis + 1
+	}
+	g.axes[axis] = value
+	return nil
+}
+
+func SetGamepadButtonPressed(id int, button GamepadButton, pressed bool) error {
+	if id < 0 || len(currentInput.gamepads) <= id {
+		return errors.New(fmt.Sprintf("ui: invalid gamepad ID: %d", id))
+	}
+	g := &currentInput.gamepads[id]
+	if button < 0 || len(g.buttonPressed) <= int(button) {
+		return errors.New(fmt.Sprintf("ui: invalid gamepad button: %d", button))
+	}
+	if g.buttonNum <= int(button) {
+		g.buttonNum = int(button) + 1
+	}
+	g.buttonPressed[button] = pressed
+	return nil
+}
+
+// MaxGamepadNum is the maximum number of the gamepads.
+const MaxGamepadNum = 16
+
 var currentInput input
 
 type input struct {
@@ -61,7 +216,14 @@ type input struct {
 	mouseButtonPressed [256]bool
 	cursorX            int
 	cursorY            int
-	gamepads           [16]gamePad
+	cursorInWindow     bool
+	gamepads           [MaxGamepadNum]gamePad
+
+	// windowCursorX and windowCursorY are the last cursor position in the window coordinates
+	// to calculate the cursor movement.
+	windowCursorX     float64
+	windowCursorY     float64
+	windowCursorValid bool
 }
 
 type gamePad struct {
@@ -70,3 +232,69 @@ type gamePad struct {
 	buttonNum     int
 	buttonPressed [256]bool
 }
+
+var currentDurations inputDurations
+
+// frameEvents are the events accumulated in a frame.
+// The wheel is in notches and the movement is in the screen coordinates.
+type frameEvents struct {
+	chars     []rune
+	wheelX    float64
+	wheelY    float64
+	movementX float64
+	movementY float64
+}
+
+var (
+	// pendingEvents are the events since the last frame.
+	pendingEvents frameEvents
+	currentEvents frameEvents
+)
+
+// addInputChar adds a typed character. Control characters are ignored.
+func addInputChar(r rune) {
+	if unicode.IsControl(r) {
+		return
+	}
+	pendingEvents.chars = append(pendingEvents.chars, r)
+}
+
+// inputDurations is kept separately from input since input is overwritten by scripted input at every frame.
+type inputDurations struct {
+	key                 [256]int
+	keyReleased         [256]bool
+	mouseButton         [256]int
+	mouseButtonReleased [256]bool
+	gamepads            [MaxGamepadNum]gamePadDurations
+}
+
+type gamePadDurations struct {
+	button         [256]int
+	buttonReleased [256]bool
+}
+
+// updateDuration increments the duration if pressed is true, or resets it.
+func updateDuration(duration *int, released *bool, pressed bool) {
+	if pressed {
+		*duration++
+		*released = false
+		return
+	}
+	*released = 0 < *duration
+	*duration = 0
+}
+
+func (d *inputDurations) update(i *input) {
+	for k, p := range i.keyPressed {
+		updateDuration(&d.key[k], &d.keyReleased[k], p)
+	}
+	for b, p := range i.mouseButtonPressed {
+		updateDuration(&d.mouseButton[b], &d.mouseButtonReleased[b], p)
+	}
+	for id := range i.gamepads {
+		g := &d.gamepads[id]
+		for b, p := range i.gamepads[id].buttonPressed {
+			updateDuration(&g.button[b], &g.buttonReleased[b], p)
+		}
+	}
+}
@@ -12,7 +12,7 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// +build !js
+// +build !js,!headless
 
 package ui
 
@@ -27,7 +27,9 @@ var glfwMouseButtonToMouseButton = map[glfw.MouseButton]MouseButton{
 	glfw.MouseButtonMiddle: MouseButtonMiddle,
 }
 
-func (i *input) update(window *glfw.Window, scale int) error {
+// update updates the input state.
+// (scaleX, scaleY) is the scale and (offsetX, offsetY) is the position of the screen in the window.
+func (i *input) update(window *glfw.Window, scaleX, scaleY, offsetX, offsetY float64) error {
 	for g, e := range glfwKeyCodeToKey {
 		i.keyPressed[e] = window.GetKey(g) == glfw.Press
 	}
@@ -35,8 +37,13 @@ func (i *input) update(window *glfw.Window, scale int) error {
 		i.mouseButtonPressed[e] = window.GetMouseButton(g) == glfw.Press
 	}
 	x, y := window.GetCursorPosition()
-	i.cursorX = int(math.Floor(x)) / scale
-	i.cursorY = int(math.Floor(y)) / scale
+	i.cursorX = int(math.Floor((x - offsetX) / scaleX))
+	i.cursorY = int(math.Floor((y - offsetY) / scaleY))
+	if i.windowCursorValid {
+		AddCursorMovement((x-i.windowCursorX)/scaleX, (y-i.windowCursorY)/scaleY)
+	}
+	i.windowCursorX, i.windowCursorY = x, y
+	i.windowCursorValid = true
 	for id := glfw.Joystick(0); id < glfw.Joystick(len(i.gamepads)); id++ {
 		if !glfw.JoystickPresent(id) {
 			continue
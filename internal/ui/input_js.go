@@ -20,6 +20,23 @@ import (
 	"github.com/gopherjs/gopherjs/js"
 )
 
+const spaceKeyCode = 32
+
+// preventedKeyCodes are the key codes of backspace, tab, space, page up, page down, end, home and the arrows.
+var preventedKeyCodes = map[int]bool{
+	8:  true,
+	9:  true,
+	32: true,
+	33: true,
+	34: true,
+	35: true,
+	36: true,
+	37: true,
+	38: true,
+	39: true,
+	40: true,
+}
+
 func (i *input) keyDown(key int) {
 	k, ok := keyCodeToKey[key]
 	if !ok {
@@ -14,7 +14,7 @@
 
 // DO NOT EDIT: This file is auto-generated by genkeys.go.
 
-// +build !js
+// +build !js,!headless
 
 package ui
 
@@ -0,0 +1,58 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ui
+
+import (
+	"math"
+)
+
+// LayoutPolicy represents how the screen is put into the outside (e.g. the window).
+type LayoutPolicy int
+
+const (
+	LayoutPolicyIntegerScale LayoutPolicy = iota
+	LayoutPolicyFit
+	LayoutPolicyFill
+	LayoutPolicyStretch
+)
+
+// Layout returns the scale and the translation to put the screen of the given size into the outside by the policy.
+// The screen is put at the center of the outside.
+func Layout(policy LayoutPolicy, width, height, outsideWidth, outsideHeight int) (sx, sy, tx, ty float64) {
+	w, h := float64(width), float64(height)
+	ow, oh := float64(outsideWidth), float64(outsideHeight)
+	sx, sy = ow/w, oh/h
+	switch policy {
+	case LayoutPolicyIntegerScale:
+		s := math.Min(sx, sy)
+		// If the outside is smaller than the screen, the scale can't be an integer.
+		if 1 <= s {
+			s = math.Floor(s)
+		}
+		sx, sy = s, s
+	case LayoutPolicyFit:
+		s := math.Min(sx, sy)
+		sx, sy = s, s
+	case LayoutPolicyFill:
+		s := math.Max(sx, sy)
+		sx, sy = s, s
+	case LayoutPolicyStretch:
+	default:
+		panic("not reach")
+	}
+	tx = math.Floor((ow - w*sx) / 2)
+	ty = math.Floor((oh - h*sy) / 2)
+	return
+}
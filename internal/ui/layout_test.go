@@ -0,0 +1,45 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ui_test
+
+import (
+	. "github.com/hajimehoshi/ebiten/internal/ui"
+	"testing"
+)
+
+func TestLayout(t *testing.T) {
+	testCases := []struct {
+		policy        LayoutPolicy
+		outsideWidth  int
+		outsideHeight int
+		sx, sy        float64
+		tx, ty        float64
+	}{
+		{LayoutPolicyIntegerScale, 640, 480, 2, 2, 0, 0},
+		{LayoutPolicyIntegerScale, 700, 500, 2, 2, 30, 10},
+		{LayoutPolicyIntegerScale, 160, 120, 0.5, 0.5, 0, 0},
+		{LayoutPolicyFit, 800, 480, 2, 2, 80, 0},
+		{LayoutPolicyFit, 480, 480, 1.5, 1.5, 0, 60},
+		{LayoutPolicyFill, 800, 480, 2.5, 2.5, 0, -60},
+		{LayoutPolicyStretch, 800, 480, 2.5, 2, 0, 0},
+	}
+	for _, c := range testCases {
+		sx, sy, tx, ty := Layout(c.policy, 320, 240, c.outsideWidth, c.outsideHeight)
+		if sx != c.sx || sy != c.sy || tx != c.tx || ty != c.ty {
+			t.Errorf("Layout(%d, 320, 240, %d, %d) = (%v, %v, %v, %v), wanted (%v, %v, %v, %v)",
+				c.policy, c.outsideWidth, c.outsideHeight, sx, sy, tx, ty, c.sx, c.sy, c.tx, c.ty)
+		}
+	}
+}
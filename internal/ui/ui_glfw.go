@@ -12,7 +12,7 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// +build !js
+// +build !js,!headless
 
 package ui
 
@@ -20,6 +20,8 @@ import (
 	"fmt"
 	glfw "github.com/go-gl/glfw3"
 	"github.com/hajimehoshi/ebiten/internal/opengl"
+	"image"
+	"math"
 	"runtime"
 	"time"
 )
@@ -61,7 +63,9 @@ func init() {
 		panic("glfw.Init() fails")
 	}
 	glfw.WindowHint(glfw.Visible, glfw.False)
-	glfw.WindowHint(glfw.Resizable, glfw.False)
+	// GLFW 3.0 can't change whether the window is resizable after the window is created.
+	// The window is always resizable and keeps its size by itself unless resizable is true.
+	glfw.WindowHint(glfw.Resizable, glfw.True)
 
 	window, err := glfw.CreateWindow(16, 16, "", nil, nil)
 	if err != nil {
@@ -72,6 +76,18 @@ func init() {
 		window: window,
 		funcs:  make(chan func()),
 	}
+	window.SetSizeCallback(func(w *glfw.Window, width, height int) {
+		u.keepWindowSize(width, height)
+	})
+	window.SetCharacterCallback(func(w *glfw.Window, char uint) {
+		addInputChar(rune(char))
+	})
+	window.SetScrollCallback(func(w *glfw.Window, xoff, yoff float64) {
+		AddWheel(xoff, yoff)
+	})
+	window.SetCursorEnterCallback(func(w *glfw.Window, entered bool) {
+		SetCursorInWindow(entered)
+	})
 	go func() {
 		runtime.LockOSThread()
 		u.window.MakeContextCurrent()
@@ -85,60 +101,227 @@ func init() {
 }
 
 type ui struct {
-	window    *glfw.Window
-	scale     int
-	glContext *opengl.Context
-	funcs     chan func()
+	window     *glfw.Window
+	width      int
+	height     int
+	scale      int
+	fullscreen bool
+	resizable  bool
+	policy     LayoutPolicy
+	glContext  *opengl.Context
+	funcs      chan func()
+
+	// windowX and windowY are the position of the window before it becomes fullscreen.
+	windowX int
+	windowY int
 }
 
-func Start(width, height, scale int, title string) (actualScale int, err error) {
+func Start(width, height, scale int, title string) error {
 	monitor, err := glfw.GetPrimaryMonitor()
 	if err != nil {
-		return 0, err
+		return err
 	}
 	videoMode, err := monitor.GetVideoMode()
 	if err != nil {
-		return 0, err
+		return err
 	}
 	x := (videoMode.Width - width*scale) / 2
 	y := (videoMode.Height - height*scale) / 3
 
-	ch := make(chan struct{})
 	ui := current
+	ui.width = width
+	ui.height = height
+	ui.scale = scale
+
 	window := ui.window
-	window.SetFramebufferSizeCallback(func(w *glfw.Window, width, height int) {
-		close(ch)
-	})
+	scaleX, scaleY := ui.framebufferScale()
 	window.SetSize(width*scale, height*scale)
 	window.SetTitle(title)
 	window.SetPosition(x, y)
 	window.Show()
+	ui.waitForFramebuffer(scaleX, scaleY)
+	// The cursor enter callback is not called for the cursor already in the window.
+	cx, cy := window.GetCursorPosition()
+	ww, wh := window.GetSize()
+	SetCursorInWindow(0 <= cx && cx < float64(ww) && 0 <= cy && cy < float64(wh))
+	return nil
+}
 
-	for {
-		done := false
-		glfw.PollEvents()
-		select {
-		case <-ch:
-			done = true
-		default:
+// FramebufferSize returns the size of the default framebuffer in pixels.
+func FramebufferSize() (width, height int) {
+	return current.window.GetFramebufferSize()
+}
+
+// DeviceScale returns the number of the framebuffer pixels per the window coordinate unit.
+func DeviceScale() float64 {
+	u := current
+	w, _ := u.window.GetSize()
+	if w == 0 {
+		return 1
+	}
+	fw, _ := u.window.GetFramebufferSize()
+	return float64(fw) / float64(w)
+}
+
+// OutsideSize returns the size of the window in the window coordinates.
+func OutsideSize() (width, height int) {
+	return current.window.GetSize()
+}
+
+func SetScreenSize(width, height int) {
+	u := current
+	u.width = width
+	u.height = height
+	u.updateWindowSize()
+}
+
+func SetScreenScale(scale int) {
+	u := current
+	u.scale = scale
+	u.updateWindowSize()
+}
+
+// SetFullscreen switches the fullscreen mode.
+// As GLFW 3.0 can't change the monitor of a window, the window is resized to cover the primary monitor.
+func SetFullscreen(fullscreen bool) error {
+	u := current
+	if u.fullscreen == fullscreen {
+		return nil
+	}
+	if fullscreen {
+		u.windowX, u.windowY = u.window.GetPosition()
+		monitor, err := glfw.GetPrimaryMonitor()
+		if err != nil {
+			return err
 		}
-		if done {
-			break
+		videoMode, err := monitor.GetVideoMode()
+		if err != nil {
+			return err
 		}
+		u.fullscreen = true
+		// The primary monitor is at the origin of the virtual screen.
+		u.window.SetPosition(0, 0)
+		u.setWindowSize(videoMode.Width, videoMode.Height)
+		return nil
 	}
+	u.fullscreen = false
+	u.updateWindowSize()
+	u.window.SetPosition(u.windowX, u.windowY)
+	return nil
+}
 
-	ui.scale = scale
+// SetWindowResizable sets whether the window can be resized by the user.
+// When the window becomes unresizable, the window is resized to fit the screen.
+func SetWindowResizable(resizable bool) {
+	u := current
+	u.resizable = resizable
+	u.updateWindowSize()
+}
+
+func SetLayoutPolicy(policy LayoutPolicy) {
+	current.policy = policy
+}
+
+func SetCursorMode(mode CursorMode) {
+	switch mode {
+	case CursorModeVisible:
+		current.window.SetInputMode(glfw.Cursor, glfw.CursorNormal)
+	case CursorModeHidden:
+		current.window.SetInputMode(glfw.Cursor, glfw.CursorHidden)
+	case CursorModeCaptured:
+		current.window.SetInputMode(glfw.Cursor, glfw.CursorDisabled)
+	default:
+		panic("not reach")
+	}
+}
+
+func SetWindowTitle(title string) {
+	current.window.SetTitle(title)
+}
+
+// SetWindowIcon does nothing on desktops since GLFW 3.0 doesn't support window icons.
+func SetWindowIcon(icon image.Image) error {
+	return nil
+}
+
+func (u *ui) updateWindowSize() {
+	if u.fullscreen || u.resizable {
+		return
+	}
+	u.setWindowSize(u.width*u.scale, u.height*u.scale)
+}
+
+const (
+	windowSizePollInterval = 10 * time.Millisecond
+	windowSizeStablePolls  = 3
+	windowSizeMaxPolls     = 100
+)
+
+// setWindowSize resizes the window and waits until the framebuffer follows the window.
+//
+// The window manager might give the window another size than the requested one (e.g. clamped to the work area),
+// or not resize it at all. The framebuffer size is compared with the size the window actually got,
+// and the waiting ends when they match for a while or after a bounded number of polls.
+// A later resize is still handled at the next frame since the graphics context is updated by the framebuffer size.
+func (u *ui) setWindowSize(width, height int) {
+	if w, h := u.window.GetSize(); w == width && h == height {
+		return
+	}
+	scaleX, scaleY := u.framebufferScale()
+	u.window.SetSize(width, height)
+	u.waitForFramebuffer(scaleX, scaleY)
+}
 
-	// For retina displays, recalculate the scale with the framebuffer size.
-	windowWidth, _ := window.GetFramebufferSize()
-	actualScale = windowWidth / width
+// framebufferScale returns the ratio of the framebuffer size to the window size, i.e. the device scale.
+func (u *ui) framebufferScale() (scaleX, scaleY float64) {
+	ww, wh := u.window.GetSize()
+	fw, fh := u.window.GetFramebufferSize()
+	if ww <= 0 || wh <= 0 || fw <= 0 || fh <= 0 {
+		return 1, 1
+	}
+	return float64(fw) / float64(ww), float64(fh) / float64(wh)
+}
 
-	return actualScale, nil
+// waitForFramebuffer polls events until the framebuffer size matches the window size scaled by scaleX and scaleY
+// for a while, or until the number of polls reaches the limit.
+func (u *ui) waitForFramebuffer(scaleX, scaleY float64) {
+	fw, fh := u.window.GetFramebufferSize()
+	stable := 0
+	for i := 0; i < windowSizeMaxPolls && stable < windowSizeStablePolls; i++ {
+		time.Sleep(windowSizePollInterval)
+		glfw.PollEvents()
+		ww, wh := u.window.GetSize()
+		nfw, nfh := u.window.GetFramebufferSize()
+		if nfw != fw || nfh != fh {
+			fw, fh = nfw, nfh
+			stable = 0
+			continue
+		}
+		if math.Abs(float64(fw)-float64(ww)*scaleX) <= 1 && math.Abs(float64(fh)-float64(wh)*scaleY) <= 1 {
+			stable++
+		} else {
+			stable = 0
+		}
+	}
+}
+
+// keepWindowSize restores the window size when the window is resized by the user while it is not resizable.
+func (u *ui) keepWindowSize(width, height int) {
+	// The screen size is not determined before Start.
+	if u.resizable || u.fullscreen || u.width == 0 {
+		return
+	}
+	w, h := u.width*u.scale, u.height*u.scale
+	if width != w || height != h {
+		u.window.SetSize(w, h)
+	}
 }
 
 func (u *ui) pollEvents() error {
 	glfw.PollEvents()
-	return currentInput.update(u.window, u.scale)
+	w, h := u.window.GetSize()
+	sx, sy, x, y := Layout(u.policy, u.width, u.height, w, h)
+	return currentInput.update(u.window, sx, sy, x, y)
 }
 
 func (u *ui) doEvents() error {
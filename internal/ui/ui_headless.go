@@ -0,0 +1,105 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build headless
+
+package ui
+
+import (
+	"github.com/hajimehoshi/ebiten/internal/opengl"
+	"image"
+)
+
+// In the headless mode, there is neither a window nor a GPU.
+// All the rendering is done by the software rasterizer on the caller's goroutine.
+
+var context *opengl.Context
+
+func Use(f func(*opengl.Context)) {
+	f(context)
+}
+
+func DoEvents() error {
+	return nil
+}
+
+func Terminate() {
+	// Do nothing.
+}
+
+func IsClosed() bool {
+	return false
+}
+
+func SwapBuffers() {
+	// Do nothing.
+}
+
+func init() {
+	context = opengl.NewContext()
+}
+
+var (
+	screenWidth  int
+	screenHeight int
+	screenScale  int
+)
+
+func Start(width, height, scale int, title string) error {
+	screenWidth = width
+	screenHeight = height
+	screenScale = scale
+	return nil
+}
+
+func FramebufferSize() (width, height int) {
+	return screenWidth * screenScale, screenHeight * screenScale
+}
+
+func DeviceScale() float64 {
+	return 1
+}
+
+func OutsideSize() (width, height int) {
+	return screenWidth * screenScale, screenHeight * screenScale
+}
+
+func SetScreenSize(width, height int) {
+	screenWidth = width
+	screenHeight = height
+}
+
+func SetScreenScale(scale int) {
+	screenScale = scale
+}
+
+func SetFullscreen(fullscreen bool) error {
+	return nil
+}
+
+func SetWindowResizable(resizable bool) {
+}
+
+func SetLayoutPolicy(policy LayoutPolicy) {
+}
+
+func SetCursorMode(mode CursorMode) {
+}
+
+func SetWindowTitle(title string) {
+}
+
+func SetWindowIcon(icon image.Image) error {
+	return nil
+}
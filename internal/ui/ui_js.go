@@ -17,15 +17,24 @@
 package ui
 
 import (
+	"bytes"
+	"encoding/base64"
 	"github.com/gopherjs/gopherjs/js"
 	"github.com/gopherjs/webgl"
 	"github.com/hajimehoshi/ebiten/internal/opengl"
+	"image"
+	"image/png"
+	"math"
 	"strconv"
 )
 
 var canvas js.Object
 var context *opengl.Context
 
+// textInput is the hidden input element which has the keyboard focus instead of the canvas.
+// IMEs compose text only in editable elements.
+var textInput js.Object
+
 func shown() bool {
 	return !js.Global.Get("document").Get("hidden").Bool()
 }
@@ -99,7 +108,7 @@ func init() {
 	bodyStyle.Set("margin", "0")
 	bodyStyle.Set("padding", "0")
 	doc.Get("body").Call("addEventListener", "click", func() {
-		canvas.Call("focus")
+		textInput.Call("focus")
 	})
 
 	canvasStyle := canvas.Get("style")
@@ -114,17 +123,59 @@ func init() {
 	}
 	context = opengl.NewContext(webglContext)
 
-	// Make the canvas focusable.
-	canvas.Call("setAttribute", "tabindex", 1)
-	canvas.Get("style").Set("outline", "none")
+	textInput = doc.Call("createElement", "input")
+	textInput.Set("type", "text")
+	textInputStyle := textInput.Get("style")
+	textInputStyle.Set("position", "absolute")
+	textInputStyle.Set("left", "0")
+	textInputStyle.Set("top", "0")
+	textInputStyle.Set("width", "1px")
+	textInputStyle.Set("height", "1px")
+	textInputStyle.Set("opacity", "0")
+	textInputStyle.Set("pointerEvents", "none")
+	doc.Get("body").Call("appendChild", textInput)
 
 	// Keyboard
-	canvas.Call("addEventListener", "keydown", func(e js.Object) {
-		e.Call("preventDefault")
+	textInput.Call("addEventListener", "keydown", func(e js.Object) {
 		code := e.Get("keyCode").Int()
+		// Preventing the default action also prevents keypress events,
+		// so this is done only for the keys which move the page.
+		if preventedKeyCodes[code] {
+			e.Call("preventDefault")
+		}
+		if code == spaceKeyCode {
+			addInputChar(' ')
+		}
 		currentInput.keyDown(code)
 	})
-	canvas.Call("addEventListener", "keyup", func(e js.Object) {
+	textInput.Call("addEventListener", "keypress", func(e js.Object) {
+		// Preventing the default action keeps the typed characters out of textInput.
+		e.Call("preventDefault")
+		addInputChar(rune(e.Get("charCode").Int()))
+	})
+	// The text composed by IMEs is taken from textInput. Other text (e.g. pasted one) is taken
+	// at input events, which are also fired during the composition.
+	composing := false
+	flushTextInput := func() {
+		for _, r := range textInput.Get("value").Str() {
+			addInputChar(r)
+		}
+		textInput.Set("value", "")
+	}
+	textInput.Call("addEventListener", "compositionstart", func(e js.Object) {
+		composing = true
+	})
+	textInput.Call("addEventListener", "compositionend", func(e js.Object) {
+		composing = false
+		flushTextInput()
+	})
+	textInput.Call("addEventListener", "input", func(e js.Object) {
+		if composing {
+			return
+		}
+		flushTextInput()
+	})
+	textInput.Call("addEventListener", "keyup", func(e js.Object) {
 		e.Call("preventDefault")
 		code := e.Get("keyCode").Int()
 		currentInput.keyUp(code)
@@ -144,45 +195,227 @@ func init() {
 	canvas.Call("addEventListener", "contextmenu", func(e js.Object) {
 		e.Call("preventDefault")
 	})
+	canvas.Call("addEventListener", "wheel", func(e js.Object) {
+		e.Call("preventDefault")
+		// Convert the deltas into notches. As GLFW, the positive y is upward.
+		d := 1.0
+		switch e.Get("deltaMode").Int() {
+		case 0: // DOM_DELTA_PIXEL
+			d = 100
+		case 1: // DOM_DELTA_LINE
+			d = 3
+		}
+		AddWheel(-e.Get("deltaX").Float()/d, -e.Get("deltaY").Float()/d)
+	})
+	canvas.Call("addEventListener", "mouseenter", func(e js.Object) {
+		SetCursorInWindow(true)
+	})
+	canvas.Call("addEventListener", "mouseleave", func(e js.Object) {
+		SetCursorInWindow(false)
+	})
+	// Browsers allow the pointer lock only in event handlers of user interactions.
+	canvas.Call("addEventListener", "click", func(e js.Object) {
+		if cursorMode == CursorModeCaptured {
+			callFirst(canvas, "requestPointerLock", "mozRequestPointerLock", "webkitRequestPointerLock")
+		}
+	})
 
 	// Gamepad
 	window.Call("addEventListener", "gamepadconnected", func(e js.Object) {
 	})
 }
 
-func devicePixelRatio() int {
-	// TODO: What if ratio is not an integer but a float?
-	ratio := js.Global.Get("window").Get("devicePixelRatio").Int()
+func devicePixelRatio() float64 {
+	ratio := js.Global.Get("window").Get("devicePixelRatio").Float()
 	if ratio == 0 {
 		ratio = 1
 	}
 	return ratio
 }
 
-func Start(width, height, scale int, title string) (actualScale int, err error) {
+var (
+	screenWidth  int
+	screenHeight int
+	screenScale  int
+	fullscreen   bool
+	resizable    bool
+	policy       LayoutPolicy
+	cursorMode   CursorMode
+)
+
+func Start(width, height, scale int, title string) error {
 	doc := js.Global.Get("document")
 	doc.Set("title", title)
-	actualScale = scale * devicePixelRatio()
-	canvas.Set("width", width*actualScale)
-	canvas.Set("height", height*actualScale)
-	canvasStyle := canvas.Get("style")
-
-	cssWidth := width * scale
-	cssHeight := height * scale
-	canvasStyle.Set("width", strconv.Itoa(cssWidth)+"px")
-	canvasStyle.Set("height", strconv.Itoa(cssHeight)+"px")
-	// CSS calc requires space chars.
-	canvasStyle.Set("left", "calc(50% - "+strconv.Itoa(cssWidth/2)+"px)")
-	canvasStyle.Set("top", "calc(50% - "+strconv.Itoa(cssHeight/2)+"px)")
+	screenWidth = width
+	screenHeight = height
+	screenScale = scale
+	updateCanvasSize()
 
 	canvas.Call("addEventListener", "mousemove", func(e js.Object) {
+		SetCursorInWindow(true)
 		rect := canvas.Call("getBoundingClientRect")
 		x, y := e.Get("clientX").Int(), e.Get("clientY").Int()
 		x -= rect.Get("left").Int()
 		y -= rect.Get("top").Int()
-		currentInput.mouseMove(x/scale, y/scale)
+		w, h := OutsideSize()
+		sx, sy, tx, ty := Layout(policy, screenWidth, screenHeight, w, h)
+		currentInput.mouseMove(int(math.Floor((float64(x)-tx)/sx)), int(math.Floor((float64(y)-ty)/sy)))
+		mx := firstFloat(e, "movementX", "mozMovementX", "webkitMovementX")
+		my := firstFloat(e, "movementY", "mozMovementY", "webkitMovementY")
+		AddCursorMovement(mx/sx, my/sy)
 	})
-	canvas.Call("focus")
+	js.Global.Get("window").Call("addEventListener", "resize", func() {
+		if resizable {
+			updateCanvasSize()
+		}
+	})
+	textInput.Call("focus")
+	// mouseenter is not fired for the cursor already on the canvas.
+	SetCursorInWindow(matches(canvas, ":hover"))
+
+	return nil
+}
+
+func updateCanvasSize() {
+	cssWidth, cssHeight := OutsideSize()
+	ratio := devicePixelRatio()
+	canvas.Set("width", int(math.Ceil(float64(cssWidth)*ratio)))
+	canvas.Set("height", int(math.Ceil(float64(cssHeight)*ratio)))
+
+	canvasStyle := canvas.Get("style")
+	canvasStyle.Set("width", strconv.Itoa(cssWidth)+"px")
+	canvasStyle.Set("height", strconv.Itoa(cssHeight)+"px")
+	// CSS calc requires space chars.
+	canvasStyle.Set("left", "calc(50% - "+strconv.Itoa(cssWidth/2)+"px)")
+	canvasStyle.Set("top", "calc(50% - "+strconv.Itoa(cssHeight/2)+"px)")
+}
+
+// DeviceScale returns the number of the canvas pixels per CSS pixel.
+func DeviceScale() float64 {
+	return devicePixelRatio()
+}
+
+// OutsideSize returns the size of the canvas in the CSS pixels.
+// When the canvas is resizable, the canvas fills the page.
+func OutsideSize() (width, height int) {
+	if resizable {
+		window := js.Global.Get("window")
+		return window.Get("innerWidth").Int(), window.Get("innerHeight").Int()
+	}
+	return screenWidth * screenScale, screenHeight * screenScale
+}
+
+// FramebufferSize returns the size of the canvas in pixels.
+func FramebufferSize() (width, height int) {
+	return canvas.Get("width").Int(), canvas.Get("height").Int()
+}
+
+func SetScreenSize(width, height int) {
+	screenWidth = width
+	screenHeight = height
+	updateCanvasSize()
+}
+
+func SetScreenScale(scale int) {
+	screenScale = scale
+	updateCanvasSize()
+}
+
+// callFirst calls the first method of obj which exists in names.
+// This is needed for the vendor-prefixed Fullscreen API.
+func callFirst(obj js.Object, names ...string) {
+	for _, name := range names {
+		if obj.Get(name) != js.Undefined {
+			obj.Call(name)
+			return
+		}
+	}
+}
+
+// SetFullscreen switches the fullscreen mode of the page. The canvas keeps its size at the center.
+// Note that browsers might allow this only in event handlers of user interactions.
+func SetFullscreen(f bool) error {
+	if fullscreen == f {
+		return nil
+	}
+	fullscreen = f
+	doc := js.Global.Get("document")
+	if f {
+		callFirst(doc.Get("documentElement"), "requestFullscreen", "webkitRequestFullscreen", "mozRequestFullScreen", "msRequestFullscreen")
+		return nil
+	}
+	callFirst(doc, "exitFullscreen", "webkitExitFullscreen", "mozCancelFullScreen", "msExitFullscreen")
+	return nil
+}
+
+func SetWindowResizable(r bool) {
+	resizable = r
+	updateCanvasSize()
+}
 
-	return actualScale, nil
+func SetLayoutPolicy(p LayoutPolicy) {
+	policy = p
+}
+
+// SetCursorMode sets the cursor mode.
+// In the captured mode, the pointer is locked at the next click if the browser doesn't allow locking it now.
+func SetCursorMode(mode CursorMode) {
+	cursorMode = mode
+	style := canvas.Get("style")
+	doc := js.Global.Get("document")
+	switch mode {
+	case CursorModeVisible:
+		style.Set("cursor", "auto")
+		callFirst(doc, "exitPointerLock", "mozExitPointerLock", "webkitExitPointerLock")
+	case CursorModeHidden:
+		style.Set("cursor", "none")
+		callFirst(doc, "exitPointerLock", "mozExitPointerLock", "webkitExitPointerLock")
+	case CursorModeCaptured:
+		style.Set("cursor", "none")
+		callFirst(canvas, "requestPointerLock", "mozRequestPointerLock", "webkitRequestPointerLock")
+	default:
+		panic("not reach")
+	}
+}
+
+// matches returns a boolean indicating whether the element matches the selector.
+// This returns false if the browser doesn't support the matches method.
+func matches(element js.Object, selector string) bool {
+	for _, name := range []string{"matches", "msMatchesSelector", "webkitMatchesSelector", "mozMatchesSelector"} {
+		if element.Get(name) != js.Undefined {
+			return element.Call(name, selector).Bool()
+		}
+	}
+	return false
+}
+
+// firstFloat returns the first property of obj which exists in names, or 0 if there is no such property.
+func firstFloat(obj js.Object, names ...string) float64 {
+	for _, name := range names {
+		if v := obj.Get(name); v != js.Undefined {
+			return v.Float()
+		}
+	}
+	return 0
+}
+
+func SetWindowTitle(title string) {
+	js.Global.Get("document").Set("title", title)
+}
+
+// SetWindowIcon sets the icon as the favicon of the page.
+func SetWindowIcon(icon image.Image) error {
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, icon); err != nil {
+		return err
+	}
+	doc := js.Global.Get("document")
+	link := doc.Call("querySelector", "link[rel=icon]")
+	if link == nil {
+		link = doc.Call("createElement", "link")
+		link.Set("rel", "icon")
+		doc.Get("head").Call("appendChild", link)
+	}
+	link.Set("href", "data:image/png;base64,"+base64.StdEncoding.EncodeToString(buf.Bytes()))
+	return nil
 }
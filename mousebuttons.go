@@ -27,3 +27,19 @@ const (
 	MouseButtonRight  = MouseButton(ui.MouseButtonRight)
 	MouseButtonMiddle = MouseButton(ui.MouseButtonMiddle)
 )
+
+// A CursorMode represents how the mouse cursor is shown.
+type CursorMode int
+
+// CursorModes
+const (
+	// The cursor is shown.
+	CursorModeVisible = CursorMode(ui.CursorModeVisible)
+
+	// The cursor is hidden while it is on the window.
+	CursorModeHidden = CursorMode(ui.CursorModeHidden)
+
+	// The cursor is hidden and captured by the window. This is useful for mouse look.
+	// Use CursorMovement to get the relative movement since the cursor position doesn't change meaningfully.
+	CursorModeCaptured = CursorMode(ui.CursorModeCaptured)
+)
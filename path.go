@@ -0,0 +1,434 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+import (
+	"errors"
+	"github.com/hajimehoshi/ebiten/internal/graphics"
+	"image"
+	"image/color"
+	"math"
+	"sync"
+)
+
+type pathOp int
+
+const (
+	pathOpMoveTo pathOp = iota
+	pathOpLineTo
+	pathOpQuadTo
+	pathOpCubicTo
+	pathOpClose
+)
+
+type pathCommand struct {
+	op     pathOp
+	points [3]point
+}
+
+// A Path represents a set of subpaths consisting of line segments and Bézier curves.
+//
+// The zero value is an empty path.
+type Path struct {
+	commands []pathCommand
+}
+
+// MoveTo starts a new subpath at (x, y).
+func (p *Path) MoveTo(x, y float64) {
+	p.commands = append(p.commands, pathCommand{op: pathOpMoveTo, points: [3]point{{x, y}}})
+}
+
+// LineTo adds a line segment to (x, y).
+//
+// If there is no current point, LineTo works as MoveTo.
+func (p *Path) LineTo(x, y float64) {
+	p.commands = append(p.commands, pathCommand{op: pathOpLineTo, points: [3]point{{x, y}}})
+}
+
+// QuadTo adds a quadratic Bézier curve with the control point (cx, cy) and the end point (x, y).
+func (p *Path) QuadTo(cx, cy, x, y float64) {
+	p.commands = append(p.commands, pathCommand{op: pathOpQuadTo, points: [3]point{{cx, cy}, {x, y}}})
+}
+
+// CubicTo adds a cubic Bézier curve with the control points (c0x, c0y) and (c1x, c1y) and the end point (x, y).
+func (p *Path) CubicTo(c0x, c0y, c1x, c1y, x, y float64) {
+	p.commands = append(p.commands, pathCommand{op: pathOpCubicTo, points: [3]point{{c0x, c0y}, {c1x, c1y}, {x, y}}})
+}
+
+// Close closes the current subpath with a line segment to its start point.
+//
+// A segment added after Close starts a new subpath from the same start point.
+func (p *Path) Close() {
+	p.commands = append(p.commands, pathCommand{op: pathOpClose})
+}
+
+func curveSegmentNum(deviation, tolerance float64) int {
+	return int(math.Max(math.Ceil(math.Sqrt(deviation/tolerance)), 1))
+}
+
+// flatten converts the path into polylines. Curves are approximated within the tolerance.
+func (p *Path) flatten(tolerance float64) []*polyline {
+	polylines := []*polyline{}
+	var cur *polyline
+	var closedStart *point
+	begin := func(pt point) {
+		cur = &polyline{points: []point{pt}}
+		polylines = append(polylines, cur)
+	}
+	for _, c := range p.commands {
+		if c.op == pathOpMoveTo {
+			begin(c.points[0])
+			continue
+		}
+		if c.op == pathOpClose {
+			if cur != nil {
+				cur.closed = true
+				closedStart = &cur.points[0]
+				cur = nil
+			}
+			continue
+		}
+		if cur == nil {
+			if closedStart != nil {
+				begin(*closedStart)
+			} else {
+				begin(c.points[0])
+			}
+		}
+		p0 := cur.points[len(cur.points)-1]
+		switch c.op {
+		case pathOpLineTo:
+			cur.points = append(cur.points, c.points[0])
+		case pathOpQuadTo:
+			p1, p2 := c.points[0], c.points[1]
+			// The maximum distance between the curve and the line segments is less than d/(4n^2).
+			d := p0.sub(p1.mul(2)).add(p2).len() / 4
+			n := curveSegmentNum(d, tolerance)
+			for i := 1; i <= n; i++ {
+				t := float64(i) / float64(n)
+				u := 1 - t
+				cur.points = append(cur.points, p0.mul(u*u).add(p1.mul(2*u*t)).add(p2.mul(t*t)))
+			}
+		case pathOpCubicTo:
+			p1, p2, p3 := c.points[0], c.points[1], c.points[2]
+			d := math.Max(p0.sub(p1.mul(2)).add(p2).len(), p1.sub(p2.mul(2)).add(p3).len()) * 3 / 4
+			n := curveSegmentNum(d, tolerance)
+			for i := 1; i <= n; i++ {
+				t := float64(i) / float64(n)
+				u := 1 - t
+				cur.points = append(cur.points, p0.mul(u*u*u).add(p1.mul(3*u*u*t)).add(p2.mul(3*u*t*t)).add(p3.mul(t*t*t)))
+			}
+		}
+	}
+	return polylines
+}
+
+// FillRule represents how the inside of a path is determined.
+type FillRule int
+
+// FillRules
+const (
+	FillRuleNonZero FillRule = iota
+	FillRuleEvenOdd
+)
+
+// LineJoin represents the shape of the joints of stroked segments.
+type LineJoin int
+
+// LineJoins
+const (
+	LineJoinMiter LineJoin = iota
+	LineJoinRound
+	LineJoinBevel
+)
+
+// LineCap represents the shape of the ends of stroked open subpaths.
+type LineCap int
+
+// LineCaps
+const (
+	LineCapButt LineCap = iota
+	LineCapRound
+	LineCapSquare
+)
+
+// A StrokeOptions represents options to stroke a path.
+type StrokeOptions struct {
+	// Width is the stroke width. The default (zero) value is 1.
+	Width float64
+
+	LineJoin LineJoin
+	LineCap  LineCap
+
+	// MiterLimit is the limit of the ratio of the miter length to the stroke width.
+	// Miter joins over the limit are drawn as bevel joins. The default (zero) value is 10.
+	MiterLimit float64
+}
+
+// A DrawPathOptions represents options to fill or stroke a path on an image.
+type DrawPathOptions struct {
+	GeoM          GeoM
+	CompositeMode CompositeMode
+
+	// FillRule is used only by FillPath.
+	FillRule FillRule
+
+	// AntiAlias enables anti-aliasing by supersampling.
+	// The offscreen images for supersampling are kept and reused by the later calls.
+	AntiAlias bool
+}
+
+// pathTolerance is the maximum distance in pixels between curves and the approximating segments.
+const pathTolerance = 0.1
+
+// pathScale returns the scale of the matrix used to convert the tolerance into the path coordinates.
+func pathScale(geo *GeoM) float64 {
+	a, b, c, d := geo.Element(0, 0), geo.Element(0, 1), geo.Element(1, 0), geo.Element(1, 1)
+	return math.Sqrt(math.Max(math.Hypot(a, c), math.Hypot(b, d)) * math.Max(math.Hypot(a, b), math.Hypot(c, d)))
+}
+
+func applyGeoM(geo *GeoM, p point) point {
+	return point{
+		geo.Element(0, 0)*p.x + geo.Element(0, 1)*p.y + geo.Element(0, 2),
+		geo.Element(1, 0)*p.x + geo.Element(1, 1)*p.y + geo.Element(1, 2),
+	}
+}
+
+// FillPath fills the inside of the path with the color.
+//
+// Open subpaths are closed implicitly.
+func (i *Image) FillPath(path *Path, clr color.Color, options *DrawPathOptions) error {
+	if options == nil {
+		options = &DrawPathOptions{}
+	}
+	scale := pathScale(&options.GeoM)
+	if scale == 0 {
+		return nil
+	}
+	polygons := [][]point{}
+	for _, l := range path.flatten(pathTolerance / scale) {
+		polygon := make([]point, len(l.points))
+		for k, p := range l.points {
+			polygon[k] = applyGeoM(&options.GeoM, p)
+		}
+		polygons = append(polygons, polygon)
+	}
+	vertices := fillTriangles(polygons, options.FillRule == FillRuleEvenOdd)
+	return i.drawPathTriangles(vertices, clr, options)
+}
+
+// StrokePath draws the outline of the path with the color.
+//
+// The stroke is transformed by options.GeoM as well as the path.
+// Overlapping parts of the stroke are drawn only once.
+func (i *Image) StrokePath(path *Path, clr color.Color, stroke *StrokeOptions, options *DrawPathOptions) error {
+	if options == nil {
+		options = &DrawPathOptions{}
+	}
+	s := StrokeOptions{}
+	if stroke != nil {
+		s = *stroke
+	}
+	if s.Width == 0 {
+		s.Width = 1
+	}
+	if s.MiterLimit == 0 {
+		s.MiterLimit = 10
+	}
+	if s.Width < 0 {
+		return errors.New("ebiten: the stroke width must not be negative")
+	}
+	scale := pathScale(&options.GeoM)
+	if scale == 0 {
+		return nil
+	}
+	tolerance := pathTolerance / scale
+	polygons := [][]point{}
+	for _, l := range path.flatten(tolerance) {
+		for _, polygon := range strokePolygons(l, &s, tolerance) {
+			for k, p := range polygon {
+				polygon[k] = applyGeoM(&options.GeoM, p)
+			}
+			polygons = append(polygons, polygon)
+		}
+	}
+	vertices := fillTriangles(polygons, false)
+	return i.drawPathTriangles(vertices, clr, options)
+}
+
+var (
+	whiteImage     *Image
+	whiteImageOnce sync.Once
+	whiteImageErr  error
+)
+
+// solidColorSource returns a 1x1 white image used as the source to draw solid colors with DrawTriangles.
+func solidColorSource() (*Image, error) {
+	whiteImageOnce.Do(func() {
+		whiteImage, whiteImageErr = NewImage(1, 1, FilterNearest)
+		if whiteImageErr != nil {
+			return
+		}
+		whiteImageErr = whiteImage.Fill(color.White)
+	})
+	return whiteImage, whiteImageErr
+}
+
+// pathSupersampling is the number of samples per pixel in each direction for anti-aliasing.
+const pathSupersampling = 4
+
+// maxPathImageSize is the maximum size of the offscreen image for anti-aliasing.
+const maxPathImageSize = 4096
+
+func (i *Image) drawPathTriangles(vertices []point, clr color.Color, options *DrawPathOptions) error {
+	if err := i.checkTarget(); err != nil {
+		return err
+	}
+	if len(vertices) == 0 {
+		return nil
+	}
+	if !options.AntiAlias {
+		return drawSolidTriangles(i, vertices, clr, 0, 0, 1, options.CompositeMode)
+	}
+
+	// Render the triangles into an enlarged offscreen image and shrink it by halves with the linear filter.
+	// Each halving averages 2x2 pixels exactly.
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, v := range vertices {
+		minX, minY = math.Min(minX, v.x), math.Min(minY, v.y)
+		maxX, maxY = math.Max(maxX, v.x), math.Max(maxY, v.y)
+	}
+	w, h := i.Size()
+	x0, y0 := int(math.Max(math.Floor(minX), 0)), int(math.Max(math.Floor(minY), 0))
+	x1, y1 := int(math.Min(math.Ceil(maxX), float64(w))), int(math.Min(math.Ceil(maxY), float64(h)))
+	if x1 <= x0 || y1 <= y0 {
+		return nil
+	}
+	n := pathSupersampling
+	for 1 < n && (maxPathImageSize < n*(x1-x0) || maxPathImageSize < n*(y1-y0)) {
+		n /= 2
+	}
+	if n == 1 {
+		return drawSolidTriangles(i, vertices, clr, 0, 0, 1, options.CompositeMode)
+	}
+	pathImagesM.Lock()
+	defer pathImagesM.Unlock()
+	w, h = x1-x0, y1-y0
+	src, err := pathImage(n, w, h)
+	if err != nil {
+		return err
+	}
+	if err := drawSolidTriangles(src, vertices, clr, float64(x0), float64(y0), float64(n), CompositeModeSourceOver); err != nil {
+		return err
+	}
+	for ; 2 < n; n /= 2 {
+		dst, err := pathImage(n/2, w, h)
+		if err != nil {
+			return err
+		}
+		op := &DrawImageOptions{}
+		op.GeoM.Scale(0.5, 0.5)
+		if err := dst.DrawImage(src.SubImage(image.Rect(0, 0, n*w, n*h)), op); err != nil {
+			return err
+		}
+		src = dst
+	}
+	op := &DrawImageOptions{}
+	op.GeoM.Scale(0.5, 0.5)
+	op.GeoM.Translate(float64(x0), float64(y0))
+	op.CompositeMode = options.CompositeMode
+	return i.DrawImage(src.SubImage(image.Rect(0, 0, 2*w, 2*h)), op)
+}
+
+var (
+	// pathImages are the offscreen images for anti-aliasing reused across the calls.
+	// pathImages[n] is for the scale n and is at least (n*w)x(n*h) for the largest w and h so far.
+	pathImages  = map[int]*Image{}
+	pathImagesM sync.Mutex
+)
+
+// pathImage returns the cleared offscreen image for the scale n whose size is at least (n*w)x(n*h).
+// Only the upper-left (n*w)x(n*h) part should be used.
+func pathImage(n, w, h int) (*Image, error) {
+	img := pathImages[n]
+	if img != nil {
+		iw, ih := img.Size()
+		if iw < n*w || ih < n*h {
+			// Grow the image so that it can be reused for the sizes used so far.
+			if w < iw/n {
+				w = iw / n
+			}
+			if h < ih/n {
+				h = ih / n
+			}
+			if err := img.Dispose(); err != nil {
+				return nil, err
+			}
+			img = nil
+		}
+	}
+	if img == nil {
+		var err error
+		img, err = NewImage(n*w, n*h, FilterLinear)
+		if err != nil {
+			return nil, err
+		}
+		pathImages[n] = img
+		return img, nil
+	}
+	if err := img.Clear(); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// drawSolidTriangles draws the triangles with the color on dst.
+// The vertices are translated by (-ox, -oy) and scaled by scale.
+func drawSolidTriangles(dst *Image, vertices []point, clr color.Color, ox, oy, scale float64, mode CompositeMode) error {
+	src, err := solidColorSource()
+	if err != nil {
+		return err
+	}
+	r, g, b, a := clr.RGBA()
+	cr, cg, cb, ca := float32(0), float32(0), float32(0), float32(a)/0xffff
+	if a != 0 {
+		cr, cg, cb = float32(r)/float32(a), float32(g)/float32(a), float32(b)/float32(a)
+	}
+	op := &DrawTrianglesOptions{CompositeMode: mode}
+	const batch = graphics.TrianglesVerticesMaxNum / 3 * 3
+	vs := make([]Vertex, 0, batch)
+	indices := make([]uint16, 0, batch)
+	for len(vertices) > 0 {
+		n := len(vertices)
+		if batch < n {
+			n = batch
+		}
+		vs, indices = vs[:0], indices[:0]
+		for k, v := range vertices[:n] {
+			vs = append(vs, Vertex{
+				DstX: float32((v.x - ox) * scale), DstY: float32((v.y - oy) * scale),
+				SrcX: 0.5, SrcY: 0.5,
+				ColorR: cr, ColorG: cg, ColorB: cb, ColorA: ca,
+			})
+			indices = append(indices, uint16(k))
+		}
+		if err := dst.DrawTriangles(vs, indices, src, op); err != nil {
+			return err
+		}
+		vertices = vertices[n:]
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten_test
+
+import (
+	. "github.com/hajimehoshi/ebiten"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func checkPathImage(t *testing.T, img *Image, want func(x, y int) color.RGBA) {
+	w, h := img.Size()
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			got := color.RGBAModel.Convert(img.At(i, j)).(color.RGBA)
+			want := want(i, j)
+			if diff(got.R, want.R) > 1 || diff(got.G, want.G) > 1 || diff(got.B, want.B) > 1 || diff(got.A, want.A) > 1 {
+				t.Errorf("img.At(%d, %d): got %#v; want %#v", i, j, got, want)
+			}
+		}
+	}
+}
+
+func TestImageFillPathConcave(t *testing.T) {
+	img, err := NewImage(8, 8, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	// An L shape
+	p := &Path{}
+	p.MoveTo(0, 0)
+	p.LineTo(4, 0)
+	p.LineTo(4, 4)
+	p.LineTo(8, 4)
+	p.LineTo(8, 8)
+	p.LineTo(0, 8)
+	p.Close()
+	if err := img.FillPath(p, color.White, nil); err != nil {
+		t.Fatal(err)
+		return
+	}
+	checkPathImage(t, img, func(x, y int) color.RGBA {
+		if x < 4 || 4 <= y {
+			return color.RGBA{0xff, 0xff, 0xff, 0xff}
+		}
+		return color.RGBA{}
+	})
+}
+
+func TestImageFillPathFillRule(t *testing.T) {
+	for _, rule := range []FillRule{FillRuleNonZero, FillRuleEvenOdd} {
+		img, err := NewImage(8, 8, FilterNearest)
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+		// Two squares in the same direction
+		p := &Path{}
+		for _, r := range []image.Rectangle{image.Rect(0, 0, 8, 8), image.Rect(2, 2, 6, 6)} {
+			p.MoveTo(float64(r.Min.X), float64(r.Min.Y))
+			p.LineTo(float64(r.Max.X), float64(r.Min.Y))
+			p.LineTo(float64(r.Max.X), float64(r.Max.Y))
+			p.LineTo(float64(r.Min.X), float64(r.Max.Y))
+			p.Close()
+		}
+		if err := img.FillPath(p, color.White, &DrawPathOptions{FillRule: rule}); err != nil {
+			t.Fatal(err)
+			return
+		}
+		checkPathImage(t, img, func(x, y int) color.RGBA {
+			if rule == FillRuleEvenOdd && image.Pt(x, y).In(image.Rect(2, 2, 6, 6)) {
+				return color.RGBA{}
+			}
+			return color.RGBA{0xff, 0xff, 0xff, 0xff}
+		})
+	}
+}
+
+func TestImageStrokePath(t *testing.T) {
+	img, err := NewImage(8, 8, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	p := &Path{}
+	p.MoveTo(2, 2)
+	p.LineTo(6, 2)
+	p.LineTo(6, 6)
+	p.LineTo(2, 6)
+	p.Close()
+	// The translucent color confirms that the overlapping corners are drawn only once.
+	clr := color.RGBA{0, 0, 0x80, 0x80}
+	if err := img.StrokePath(p, clr, &StrokeOptions{Width: 2}, nil); err != nil {
+		t.Fatal(err)
+		return
+	}
+	checkPathImage(t, img, func(x, y int) color.RGBA {
+		pt := image.Pt(x, y)
+		if pt.In(image.Rect(1, 1, 7, 7)) && !pt.In(image.Rect(3, 3, 5, 5)) {
+			return clr
+		}
+		return color.RGBA{}
+	})
+}
+
+func TestImageFillPathAntiAlias(t *testing.T) {
+	img, err := NewImage(4, 4, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	p := &Path{}
+	p.MoveTo(0, 0)
+	p.LineTo(2.5, 0)
+	p.LineTo(2.5, 4)
+	p.LineTo(0, 4)
+	if err := img.FillPath(p, color.White, &DrawPathOptions{AntiAlias: true}); err != nil {
+		t.Fatal(err)
+		return
+	}
+	checkPathImage(t, img, func(x, y int) color.RGBA {
+		switch {
+		case x < 2:
+			return color.RGBA{0xff, 0xff, 0xff, 0xff}
+		case x == 2:
+			return color.RGBA{0x80, 0x80, 0x80, 0x80}
+		}
+		return color.RGBA{}
+	})
+}
+
+func TestImageFillPathAntiAliasReuse(t *testing.T) {
+	// The offscreen images for anti-aliasing are reused: a larger path and then a smaller one
+	// must not leave the former's pixels in the latter's result.
+	large, err := NewImage(16, 16, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	p := &Path{}
+	p.MoveTo(0, 0)
+	p.LineTo(16, 0)
+	p.LineTo(16, 16)
+	p.LineTo(0, 16)
+	if err := large.FillPath(p, color.White, &DrawPathOptions{AntiAlias: true}); err != nil {
+		t.Fatal(err)
+		return
+	}
+	for k := 0; k < 2; k++ {
+		img, err := NewImage(4, 4, FilterNearest)
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+		p := &Path{}
+		p.MoveTo(0, 0)
+		p.LineTo(2.5, 0)
+		p.LineTo(2.5, 2)
+		p.LineTo(0, 2)
+		if err := img.FillPath(p, color.White, &DrawPathOptions{AntiAlias: true}); err != nil {
+			t.Fatal(err)
+			return
+		}
+		checkPathImage(t, img, func(x, y int) color.RGBA {
+			switch {
+			case 2 <= y:
+				return color.RGBA{}
+			case x < 2:
+				return color.RGBA{0xff, 0xff, 0xff, 0xff}
+			case x == 2:
+				return color.RGBA{0x80, 0x80, 0x80, 0x80}
+			}
+			return color.RGBA{}
+		})
+	}
+}
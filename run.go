@@ -15,8 +15,13 @@
 package ebiten
 
 import (
+	"errors"
+	"fmt"
+	"github.com/hajimehoshi/ebiten/internal/hooks"
 	"github.com/hajimehoshi/ebiten/internal/opengl"
 	"github.com/hajimehoshi/ebiten/internal/ui"
+	"image"
+	"math"
 	"time"
 )
 
@@ -37,20 +42,21 @@ func CurrentFPS() float64 {
 // but this is not strictly guaranteed.
 // If you need to care about time, you need to check current time every time f is called.
 func Run(f func(*Image) error, width, height, scale int, title string) error {
-	actualScale, err := ui.Start(width, height, scale, title)
-	if err != nil {
+	if err := ui.Start(width, height, scale, title); err != nil {
 		return err
 	}
 	defer ui.Terminate()
 
-	var graphicsContext *graphicsContext
-	ui.Use(func(c *opengl.Context) {
-		graphicsContext, err = newGraphicsContext(c, width, height, actualScale)
-	})
-	if err != nil {
-		return err
+	currentRunContext = &runContext{
+		width:  width,
+		height: height,
+		scale:  scale,
 	}
+	defer func() {
+		currentRunContext = nil
+	}()
 
+	var graphicsContext *graphicsContext
 	frames := 0
 	t := time.Now().UnixNano()
 	for {
@@ -60,6 +66,12 @@ func Run(f func(*Image) error, width, height, scale int, title string) error {
 		if ui.IsClosed() {
 			return nil
 		}
+		ui.UpdateInputFrame()
+		var err error
+		graphicsContext, err = currentRunContext.updateGraphicsContext(graphicsContext)
+		if err != nil {
+			return err
+		}
 		if err := graphicsContext.preUpdate(); err != nil {
 			return err
 		}
@@ -69,6 +81,9 @@ func Run(f func(*Image) error, width, height, scale int, title string) error {
 		if err := graphicsContext.postUpdate(); err != nil {
 			return err
 		}
+		if err := hooks.Run(); err != nil {
+			return err
+		}
 		ui.SwapBuffers()
 		if err != nil {
 			return err
@@ -84,3 +99,417 @@ func Run(f func(*Image) error, width, height, scale int, title string) error {
 		}
 	}
 }
+
+// A runContext is the state of the running game.
+type runContext struct {
+	width      int
+	height     int
+	scale      int
+	fullscreen bool
+	resizable  bool
+	policy     LayoutPolicy
+	highDPI    bool
+
+	// headless is true for RunHeadless, which has no window.
+	headless bool
+
+	// screenChanged is true when the graphics context needs to be recreated.
+	screenChanged bool
+}
+
+// currentRunContext is nil when the game is not running.
+var currentRunContext *runContext
+
+// updateGraphicsContext returns a new graphics context if g is nil, or the screen or the outside is changed.
+// Otherwise, this returns g.
+func (r *runContext) updateGraphicsContext(g *graphicsContext) (*graphicsContext, error) {
+	outsideWidth, outsideHeight := r.width*r.scale, r.height*r.scale
+	framebufferWidth, framebufferHeight := outsideWidth, outsideHeight
+	deviceScale := 1.0
+	if !r.headless {
+		outsideWidth, outsideHeight = ui.OutsideSize()
+		framebufferWidth, framebufferHeight = ui.FramebufferSize()
+		deviceScale = ui.DeviceScale()
+	}
+	if g != nil && !r.screenChanged && g.outsideWidth == framebufferWidth && g.outsideHeight == framebufferHeight {
+		return g, nil
+	}
+	r.screenChanged = false
+
+	screenWidth, screenHeight, geo := screenGeometry(r.width, r.height, outsideWidth, outsideHeight, r.policy, deviceScale, r.highDPI)
+	var err error
+	ui.Use(func(c *opengl.Context) {
+		if g != nil {
+			if err = g.dispose(); err != nil {
+				return
+			}
+		}
+		g, err = newGraphicsContext(c, screenWidth, screenHeight, framebufferWidth, framebufferHeight, geo)
+	})
+	return g, err
+}
+
+// screenGeometry returns the size of the screen image and the matrix to put the screen onto the framebuffer.
+//
+// The layout is calculated in the device-independent pixels and then scaled by the device scale.
+// If highDPI is true, the screen image has the device resolution, which is rounded up to integers.
+func screenGeometry(width, height, outsideWidth, outsideHeight int, policy LayoutPolicy, deviceScale float64, highDPI bool) (screenWidth, screenHeight int, geo GeoM) {
+	screenWidth, screenHeight = width, height
+	resolution := 1.0
+	if highDPI {
+		resolution = deviceScale
+		screenWidth = int(math.Ceil(float64(width) * resolution))
+		screenHeight = int(math.Ceil(float64(height) * resolution))
+	}
+	sx, sy, tx, ty := ui.Layout(ui.LayoutPolicy(policy), width, height, outsideWidth, outsideHeight)
+	geo = ScaleGeo(sx*deviceScale/resolution, sy*deviceScale/resolution)
+	geo.Translate(math.Floor(tx*deviceScale), math.Floor(ty*deviceScale))
+	return
+}
+
+var errNotRunning = errors.New("ebiten: the game is not running")
+
+// SetScreenSize changes the size of the screen.
+//
+// The screen image passed to the game function is recreated with the new size at the next frame.
+// This function must be called while the game is running, e.g. in the game function.
+func SetScreenSize(width, height int) error {
+	r := currentRunContext
+	if r == nil {
+		return errNotRunning
+	}
+	if width <= 0 || height <= 0 {
+		return errors.New("ebiten: width and height must be positive")
+	}
+	if r.width == width && r.height == height {
+		return nil
+	}
+	r.width = width
+	r.height = height
+	r.screenChanged = true
+	if !r.headless {
+		ui.SetScreenSize(width, height)
+	}
+	return nil
+}
+
+// SetScreenScale changes the scale of the screen.
+//
+// The window is resized unless the window is in the fullscreen mode or resizable.
+// This function must be called while the game is running, e.g. in the game function.
+func SetScreenScale(scale int) error {
+	r := currentRunContext
+	if r == nil {
+		return errNotRunning
+	}
+	if scale <= 0 {
+		return errors.New("ebiten: scale must be positive")
+	}
+	if r.scale == scale {
+		return nil
+	}
+	r.scale = scale
+	r.screenChanged = true
+	if !r.headless {
+		ui.SetScreenScale(scale)
+	}
+	return nil
+}
+
+// SetFullscreen switches the fullscreen mode.
+//
+// In the fullscreen mode, the screen is put into the monitor by the layout policy.
+// On desktops, the window is resized to cover the primary monitor.
+// On browsers, the page becomes fullscreen and the canvas keeps its size.
+//
+// This function must be called while the game is running, e.g. in the game function.
+func SetFullscreen(fullscreen bool) error {
+	r := currentRunContext
+	if r == nil {
+		return errNotRunning
+	}
+	if r.fullscreen == fullscreen {
+		return nil
+	}
+	r.fullscreen = fullscreen
+	r.screenChanged = true
+	if !r.headless {
+		return ui.SetFullscreen(fullscreen)
+	}
+	return nil
+}
+
+// IsFullscreen returns a boolean indicating whether the game is in the fullscreen mode.
+func IsFullscreen() bool {
+	r := currentRunContext
+	return r != nil && r.fullscreen
+}
+
+// A LayoutPolicy represents how the screen is put into the window when their sizes don't match,
+// e.g. in the fullscreen mode or when the window is resized by the user.
+// The screen is always put at the center of the window.
+type LayoutPolicy int
+
+// LayoutPolicies
+const (
+	// The screen is scaled by the largest integer to fit the window.
+	// If the window is smaller than the screen, the screen is shrunk to fit the window.
+	LayoutPolicyIntegerScale LayoutPolicy = LayoutPolicy(ui.LayoutPolicyIntegerScale)
+
+	// The screen is scaled to fit the window keeping its aspect ratio.
+	LayoutPolicyFit LayoutPolicy = LayoutPolicy(ui.LayoutPolicyFit)
+
+	// The screen is scaled to cover the window keeping its aspect ratio. The screen might be cropped.
+	LayoutPolicyFill LayoutPolicy = LayoutPolicy(ui.LayoutPolicyFill)
+
+	// The screen is stretched to the window.
+	LayoutPolicyStretch LayoutPolicy = LayoutPolicy(ui.LayoutPolicyStretch)
+)
+
+// SetLayoutPolicy changes the layout policy. The default value is LayoutPolicyIntegerScale.
+//
+// This function must be called while the game is running, e.g. in the game function.
+func SetLayoutPolicy(policy LayoutPolicy) error {
+	r := currentRunContext
+	if r == nil {
+		return errNotRunning
+	}
+	if policy < LayoutPolicyIntegerScale || LayoutPolicyStretch < policy {
+		return errors.New(fmt.Sprintf("ebiten: invalid layout policy: %d", policy))
+	}
+	if r.policy == policy {
+		return nil
+	}
+	r.policy = policy
+	r.screenChanged = true
+	if !r.headless {
+		ui.SetLayoutPolicy(ui.LayoutPolicy(policy))
+	}
+	return nil
+}
+
+// SetWindowResizable sets whether the window can be resized by the user.
+//
+// While the window is resizable, SetScreenSize and SetScreenScale don't resize the window,
+// and the screen is put into the window by the layout policy.
+// The game can query the window size by OutsideSize at every frame and choose the screen size by SetScreenSize.
+// When the window becomes unresizable, the window is resized to fit the screen.
+// On browsers, the canvas fills the page while it is resizable.
+//
+// On desktops, the window is always resizable for the window system since GLFW 3.0 can't change it after
+// the window is created: the window might have a resizing border or a maximize button even while it is not resizable,
+// and the window is resized back to the screen size as soon as the user resizes it.
+//
+// This function must be called while the game is running, e.g. in the game function.
+func SetWindowResizable(resizable bool) error {
+	r := currentRunContext
+	if r == nil {
+		return errNotRunning
+	}
+	if r.resizable == resizable {
+		return nil
+	}
+	r.resizable = resizable
+	if !r.headless {
+		ui.SetWindowResizable(resizable)
+	}
+	return nil
+}
+
+// IsWindowResizable returns a boolean indicating whether the window is resizable.
+func IsWindowResizable() bool {
+	r := currentRunContext
+	return r != nil && r.resizable
+}
+
+// OutsideSize returns the size of the window (or the canvas on browsers) in the device-independent pixels.
+//
+// OutsideSize returns (0, 0) when the game is not running.
+func OutsideSize() (width, height int) {
+	r := currentRunContext
+	if r == nil {
+		return 0, 0
+	}
+	if r.headless {
+		return r.width * r.scale, r.height * r.scale
+	}
+	return ui.OutsideSize()
+}
+
+// DeviceScaleFactor returns the number of the device pixels per device-independent pixel.
+// For example, this is 2 on retina displays and can be a fraction like 1.5 on some monitors.
+//
+// In RunHeadless, DeviceScaleFactor returns 1.
+func DeviceScaleFactor() float64 {
+	r := currentRunContext
+	if r != nil && r.headless {
+		return 1
+	}
+	return ui.DeviceScale()
+}
+
+// SetHighDPI sets whether the screen image is rendered at the device resolution.
+//
+// When highDPI is true, the size of the screen image passed to the game function is
+// the screen size multiplied by DeviceScaleFactor (rounded up), and the game is expected to scale
+// its rendering by DeviceScaleFactor. This keeps e.g. texts crisp on high-DPI displays.
+// The cursor position is still in the screen size.
+//
+// This function must be called while the game is running, e.g. in the game function.
+func SetHighDPI(highDPI bool) error {
+	r := currentRunContext
+	if r == nil {
+		return errNotRunning
+	}
+	if r.highDPI == highDPI {
+		return nil
+	}
+	r.highDPI = highDPI
+	r.screenChanged = true
+	return nil
+}
+
+// IsHighDPI returns a boolean indicating whether the screen image is rendered at the device resolution.
+func IsHighDPI() bool {
+	r := currentRunContext
+	return r != nil && r.highDPI
+}
+
+// SetWindowTitle changes the title of the window (or the page on browsers).
+func SetWindowTitle(title string) {
+	ui.SetWindowTitle(title)
+}
+
+// SetWindowIcon changes the icon of the window.
+//
+// For now, this works only on browsers, where the icon is used as the favicon.
+// On desktops, this silently does nothing.
+//
+// SetWindowIcon returns an error when icon is nil or empty, or when icon can't be encoded.
+func SetWindowIcon(icon image.Image) error {
+	if icon == nil {
+		return errors.New("ebiten: the icon must not be nil")
+	}
+	if icon.Bounds().Empty() {
+		return errors.New("ebiten: the icon must not be empty")
+	}
+	return ui.SetWindowIcon(icon)
+}
+
+// An InputState represents the state of the input devices at a frame.
+type InputState struct {
+	Keys            []Key
+	MouseButtons    []MouseButton
+	CursorX         int
+	CursorY         int
+	CursorInWindow  bool
+	CursorMovementX float64
+	CursorMovementY float64
+	WheelX          float64
+	WheelY          float64
+	Chars           []rune
+	Gamepads        []GamepadState
+}
+
+// A GamepadState represents the state of a gamepad at a frame.
+// The index of Gamepads in InputState is the gamepad ID.
+type GamepadState struct {
+	Axes    []float64
+	Buttons []GamepadButton
+}
+
+func (s *InputState) apply() error {
+	ui.ResetInput()
+	for _, k := range s.Keys {
+		if err := ui.SetKeyPressed(ui.Key(k), true); err != nil {
+			return err
+		}
+	}
+	for _, b := range s.MouseButtons {
+		if err := ui.SetMouseButtonPressed(ui.MouseButton(b), true); err != nil {
+			return err
+		}
+	}
+	ui.SetCursorPosition(s.CursorX, s.CursorY)
+	ui.SetCursorInWindow(s.CursorInWindow)
+	ui.AddCursorMovement(s.CursorMovementX, s.CursorMovementY)
+	ui.AddWheel(s.WheelX, s.WheelY)
+	ui.AddInputChars(s.Chars)
+	if ui.MaxGamepadNum < len(s.Gamepads) {
+		return errors.New(fmt.Sprintf("ebiten: the number of gamepads must be equal to or less than %d", ui.MaxGamepadNum))
+	}
+	for id, g := range s.Gamepads {
+		for a, v := range g.Axes {
+			if err := ui.SetGamepadAxis(id, a, v); err != nil {
+				return err
+			}
+		}
+		for _, b := range g.Buttons {
+			if err := ui.SetGamepadButtonPressed(id, ui.GamepadButton(b), true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RunHeadless runs the game for the given number of frames without showing a window,
+// and returns the screen after the last frame.
+//
+// inputs[i] is the input state at the i-th frame.
+// When inputs is shorter than frames, no keys or buttons are pressed at the rest of the frames.
+// The actual input devices are ignored.
+// RunHeadless returns an error when frames is not positive,
+// or when an input state has an invalid key, button, gamepad ID or axis.
+//
+// Unlike Run, RunHeadless doesn't wait for vsync and returns after the frames are processed.
+// This is useful for deterministic tests, especially with the build tag headless.
+func RunHeadless(f func(*Image) error, width, height, frames int, inputs []InputState) (*Image, error) {
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("ebiten: width and height must be positive")
+	}
+	if frames <= 0 {
+		return nil, errors.New("ebiten: frames must be positive")
+	}
+	currentRunContext = &runContext{
+		width:    width,
+		height:   height,
+		scale:    1,
+		headless: true,
+	}
+	defer func() {
+		currentRunContext = nil
+	}()
+	defer ui.ResetInput()
+	defer ui.ResetInputFrame()
+
+	var graphicsContext *graphicsContext
+	for i := 0; i < frames; i++ {
+		if i < len(inputs) {
+			if err := inputs[i].apply(); err != nil {
+				return nil, err
+			}
+		} else {
+			ui.ResetInput()
+		}
+		ui.UpdateInputFrame()
+		var err error
+		graphicsContext, err = currentRunContext.updateGraphicsContext(graphicsContext)
+		if err != nil {
+			return nil, err
+		}
+		if err := graphicsContext.preUpdate(); err != nil {
+			return nil, err
+		}
+		if err := f(graphicsContext.screen); err != nil {
+			return nil, err
+		}
+		if err := graphicsContext.postUpdate(); err != nil {
+			return nil, err
+		}
+		if err := hooks.Run(); err != nil {
+			return nil, err
+		}
+	}
+	return graphicsContext.screen, nil
+}
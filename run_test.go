@@ -0,0 +1,414 @@
+// Copyright 2014 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten_test
+
+import (
+	. "github.com/hajimehoshi/ebiten"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestRunHeadless(t *testing.T) {
+	inputs := []InputState{
+		{},
+		{Keys: []Key{KeyRight}},
+		{Keys: []Key{KeyRight}, CursorX: 3, CursorY: 5},
+		{Gamepads: []GamepadState{{Buttons: []GamepadButton{GamepadButton2}}}},
+	}
+	x := 0
+	frame := 0
+	update := func(screen *Image) error {
+		if IsKeyPressed(KeyRight) {
+			x++
+		}
+		if frame == 2 {
+			if cx, cy := CursorPosition(); cx != 3 || cy != 5 {
+				t.Errorf("frame %d: CursorPosition(): got (%d, %d); want (3, 5)", frame, cx, cy)
+			}
+		}
+		if got, want := IsGamepadButtonPressed(0, GamepadButton2), frame == 3; got != want {
+			t.Errorf("frame %d: IsGamepadButtonPressed(0, GamepadButton2): got %t; want %t", frame, got, want)
+		}
+		frame++
+		return screen.DrawFilledRect(x, 0, 1, 1, color.White)
+	}
+	screen, err := RunHeadless(update, 16, 16, 5, inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frame != 5 {
+		t.Errorf("frames: got %d; want 5", frame)
+	}
+	for i := 0; i < 4; i++ {
+		got := screen.At(i, 0)
+		want := color.RGBA{}
+		if i == 2 {
+			want = color.RGBA{0xff, 0xff, 0xff, 0xff}
+		}
+		if got != want {
+			t.Errorf("screen.At(%d, 0): got %#v; want %#v", i, got, want)
+		}
+	}
+}
+
+func TestSetScreenSize(t *testing.T) {
+	if err := SetScreenSize(8, 8); err == nil {
+		t.Errorf("SetScreenSize must return an error when the game is not running")
+	}
+	frame := 0
+	update := func(screen *Image) error {
+		w, h := screen.Size()
+		want := 16
+		if 1 <= frame {
+			want = 8
+		}
+		if w != want || h != want {
+			t.Errorf("frame %d: screen.Size(): got (%d, %d); want (%d, %d)", frame, w, h, want, want)
+		}
+		if frame == 0 {
+			if err := SetScreenSize(8, 8); err != nil {
+				return err
+			}
+		}
+		frame++
+		return screen.Fill(color.White)
+	}
+	screen, err := RunHeadless(update, 16, 16, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w, h := screen.Size(); w != 8 || h != 8 {
+		t.Errorf("screen.Size(): got (%d, %d); want (8, 8)", w, h)
+	}
+	if got, want := screen.At(7, 7), (color.RGBA{0xff, 0xff, 0xff, 0xff}); got != want {
+		t.Errorf("screen.At(7, 7): got %#v; want %#v", got, want)
+	}
+}
+
+func TestWindowResizable(t *testing.T) {
+	if err := SetWindowResizable(true); err == nil {
+		t.Errorf("SetWindowResizable must return an error when the game is not running")
+	}
+	if err := SetLayoutPolicy(LayoutPolicyFit); err == nil {
+		t.Errorf("SetLayoutPolicy must return an error when the game is not running")
+	}
+	frame := 0
+	update := func(screen *Image) error {
+		if frame == 0 {
+			if w, h := OutsideSize(); w != 16 || h != 16 {
+				t.Errorf("OutsideSize(): got (%d, %d); want (16, 16)", w, h)
+			}
+			if err := SetWindowResizable(true); err != nil {
+				return err
+			}
+			if err := SetLayoutPolicy(LayoutPolicyStretch); err != nil {
+				return err
+			}
+			for _, p := range []LayoutPolicy{-1, LayoutPolicyStretch + 1} {
+				if err := SetLayoutPolicy(p); err == nil {
+					t.Errorf("SetLayoutPolicy(%d) must return an error", p)
+				}
+			}
+		}
+		if got, want := IsWindowResizable(), true; got != want {
+			t.Errorf("frame %d: IsWindowResizable(): got %t; want %t", frame, got, want)
+		}
+		frame++
+		return screen.Fill(color.White)
+	}
+	screen, err := RunHeadless(update, 16, 16, 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := screen.At(15, 15), (color.RGBA{0xff, 0xff, 0xff, 0xff}); got != want {
+		t.Errorf("screen.At(15, 15): got %#v; want %#v", got, want)
+	}
+	if IsWindowResizable() {
+		t.Errorf("IsWindowResizable must return false after the game ends")
+	}
+}
+
+func TestHighDPI(t *testing.T) {
+	if err := SetHighDPI(true); err == nil {
+		t.Errorf("SetHighDPI must return an error when the game is not running")
+	}
+	frame := 0
+	update := func(screen *Image) error {
+		if got, want := DeviceScaleFactor(), 1.0; got != want {
+			t.Errorf("DeviceScaleFactor(): got %v; want %v", got, want)
+		}
+		if frame == 0 {
+			if err := SetHighDPI(true); err != nil {
+				return err
+			}
+		}
+		if got, want := IsHighDPI(), true; got != want {
+			t.Errorf("frame %d: IsHighDPI(): got %t; want %t", frame, got, want)
+		}
+		// The device scale is 1 in RunHeadless.
+		if w, h := screen.Size(); w != 16 || h != 16 {
+			t.Errorf("frame %d: screen.Size(): got (%d, %d); want (16, 16)", frame, w, h)
+		}
+		frame++
+		return nil
+	}
+	if _, err := RunHeadless(update, 16, 16, 2, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScreenGeometry(t *testing.T) {
+	// The screen is 100x75 and the window is 250x150 in the device-independent pixels.
+	// The screen is scaled by 2 and put at the center: the translation is (25, 0).
+	testCases := []struct {
+		deviceScale  float64
+		highDPI      bool
+		screenWidth  int
+		screenHeight int
+		scale        float64
+		tx           float64
+	}{
+		{1, false, 100, 75, 2, 25},
+		{1, true, 100, 75, 2, 25},
+		{1.25, false, 100, 75, 2.5, 31},
+		{1.25, true, 125, 94, 2, 31},
+		{1.5, false, 100, 75, 3, 37},
+		{1.5, true, 150, 113, 2, 37},
+		{2, false, 100, 75, 4, 50},
+		{2, true, 200, 150, 2, 50},
+	}
+	for _, c := range testCases {
+		w, h, geo := ScreenGeometry(100, 75, 250, 150, LayoutPolicyIntegerScale, c.deviceScale, c.highDPI)
+		if w != c.screenWidth || h != c.screenHeight {
+			t.Errorf("deviceScale: %v, highDPI: %t: the screen size: got (%d, %d); want (%d, %d)", c.deviceScale, c.highDPI, w, h, c.screenWidth, c.screenHeight)
+		}
+		got := [2][3]float64{}
+		for i := 0; i < 2; i++ {
+			for j := 0; j < 3; j++ {
+				got[i][j] = geo.Element(i, j)
+			}
+		}
+		want := [2][3]float64{{c.scale, 0, c.tx}, {0, c.scale, 0}}
+		if got != want {
+			t.Errorf("deviceScale: %v, highDPI: %t: the geometry: got %v; want %v", c.deviceScale, c.highDPI, got, want)
+		}
+	}
+}
+
+func TestInputDurations(t *testing.T) {
+	inputs := []InputState{
+		{},
+		{Keys: []Key{KeyRight}, MouseButtons: []MouseButton{MouseButtonLeft}, Gamepads: []GamepadState{{Buttons: []GamepadButton{GamepadButton1}}}},
+		{Keys: []Key{KeyRight}, MouseButtons: []MouseButton{MouseButtonLeft}, Gamepads: []GamepadState{{Buttons: []GamepadButton{GamepadButton1}}}},
+		{},
+	}
+	durations := []int{0, 1, 2, 0}
+	frame := 0
+	update := func(screen *Image) error {
+		want := durations[frame]
+		if got := KeyPressDuration(KeyRight); got != want {
+			t.Errorf("frame %d: KeyPressDuration(KeyRight): got %d; want %d", frame, got, want)
+		}
+		if got := MouseButtonPressDuration(MouseButtonLeft); got != want {
+			t.Errorf("frame %d: MouseButtonPressDuration(MouseButtonLeft): got %d; want %d", frame, got, want)
+		}
+		if got := GamepadButtonPressDuration(0, GamepadButton1); got != want {
+			t.Errorf("frame %d: GamepadButtonPressDuration(0, GamepadButton1): got %d; want %d", frame, got, want)
+		}
+		justPressed := frame == 1
+		if got := IsKeyJustPressed(KeyRight); got != justPressed {
+			t.Errorf("frame %d: IsKeyJustPressed(KeyRight): got %t; want %t", frame, got, justPressed)
+		}
+		if got := IsMouseButtonJustPressed(MouseButtonLeft); got != justPressed {
+			t.Errorf("frame %d: IsMouseButtonJustPressed(MouseButtonLeft): got %t; want %t", frame, got, justPressed)
+		}
+		if got := IsGamepadButtonJustPressed(0, GamepadButton1); got != justPressed {
+			t.Errorf("frame %d: IsGamepadButtonJustPressed(0, GamepadButton1): got %t; want %t", frame, got, justPressed)
+		}
+		justReleased := frame == 3
+		if got := IsKeyJustReleased(KeyRight); got != justReleased {
+			t.Errorf("frame %d: IsKeyJustReleased(KeyRight): got %t; want %t", frame, got, justReleased)
+		}
+		if got := IsMouseButtonJustReleased(MouseButtonLeft); got != justReleased {
+			t.Errorf("frame %d: IsMouseButtonJustReleased(MouseButtonLeft): got %t; want %t", frame, got, justReleased)
+		}
+		if got := IsGamepadButtonJustReleased(0, GamepadButton1); got != justReleased {
+			t.Errorf("frame %d: IsGamepadButtonJustReleased(0, GamepadButton1): got %t; want %t", frame, got, justReleased)
+		}
+		frame++
+		return nil
+	}
+	if _, err := RunHeadless(update, 16, 16, len(inputs), inputs); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInputChars(t *testing.T) {
+	inputs := []InputState{
+		{Chars: []rune("ab")},
+		{},
+		{Chars: []rune("\bあ")},
+	}
+	want := []string{"ab", "", "あ"}
+	frame := 0
+	update := func(screen *Image) error {
+		if got := string(InputChars()); got != want[frame] {
+			t.Errorf("frame %d: InputChars(): got %q; want %q", frame, got, want[frame])
+		}
+		frame++
+		return nil
+	}
+	if _, err := RunHeadless(update, 16, 16, len(inputs), inputs); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIsKeyRepeated(t *testing.T) {
+	const frames = 12
+	inputs := make([]InputState, frames)
+	for i := range inputs {
+		inputs[i].Keys = []Key{KeyBackspace}
+	}
+	var got []int
+	frame := 0
+	update := func(screen *Image) error {
+		if IsKeyRepeated(KeyBackspace, 5, 3) {
+			got = append(got, frame)
+		}
+		frame++
+		return nil
+	}
+	if _, err := RunHeadless(update, 16, 16, frames, inputs); err != nil {
+		t.Fatal(err)
+	}
+	want := []int{0, 5, 8, 11}
+	if len(got) != len(want) {
+		t.Fatalf("repeated frames: got %v; want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("repeated frames: got %v; want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestWheelAndCursorMovement(t *testing.T) {
+	inputs := []InputState{
+		{CursorInWindow: true, WheelY: 1, CursorMovementX: 2, CursorMovementY: -3},
+		{},
+	}
+	frame := 0
+	update := func(screen *Image) error {
+		want := frame == 0
+		if got := IsCursorInWindow(); got != want {
+			t.Errorf("frame %d: IsCursorInWindow(): got %t; want %t", frame, got, want)
+		}
+		wx, wy := Wheel()
+		dx, dy := CursorMovement()
+		if want {
+			if wx != 0 || wy != 1 {
+				t.Errorf("frame %d: Wheel(): got (%v, %v); want (0, 1)", frame, wx, wy)
+			}
+			if dx != 2 || dy != -3 {
+				t.Errorf("frame %d: CursorMovement(): got (%v, %v); want (2, -3)", frame, dx, dy)
+			}
+		} else {
+			if wx != 0 || wy != 0 {
+				t.Errorf("frame %d: Wheel(): got (%v, %v); want (0, 0)", frame, wx, wy)
+			}
+			if dx != 0 || dy != 0 {
+				t.Errorf("frame %d: CursorMovement(): got (%v, %v); want (0, 0)", frame, dx, dy)
+			}
+		}
+		frame++
+		return nil
+	}
+	if _, err := RunHeadless(update, 16, 16, len(inputs), inputs); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunHeadlessInvalidInput(t *testing.T) {
+	update := func(screen *Image) error {
+		return nil
+	}
+	inputs := [][]InputState{
+		{{Keys: []Key{-1}}},
+		{{MouseButtons: []MouseButton{256}}},
+		{{Gamepads: make([]GamepadState, 17)}},
+		{{Gamepads: []GamepadState{{Axes: make([]float64, 17)}}}},
+		{{Gamepads: []GamepadState{{Buttons: []GamepadButton{-1}}}}},
+	}
+	for i, in := range inputs {
+		if _, err := RunHeadless(update, 16, 16, 1, in); err == nil {
+			t.Errorf("RunHeadless with inputs[%d] must return an error", i)
+		}
+	}
+}
+
+func TestRunHeadlessInvalidSize(t *testing.T) {
+	called := false
+	update := func(screen *Image) error {
+		called = true
+		return nil
+	}
+	testCases := []struct {
+		width  int
+		height int
+		frames int
+	}{
+		{16, 16, 0},
+		{16, 16, -1},
+		{0, 16, 1},
+		{16, 0, 1},
+	}
+	for _, c := range testCases {
+		if _, err := RunHeadless(update, c.width, c.height, c.frames, nil); err == nil {
+			t.Errorf("RunHeadless(f, %d, %d, %d, nil) must return an error", c.width, c.height, c.frames)
+		}
+	}
+	if called {
+		t.Errorf("the game function must not be called")
+	}
+}
+
+func TestSetWindowIcon(t *testing.T) {
+	if err := SetWindowIcon(nil); err == nil {
+		t.Errorf("SetWindowIcon(nil) must return an error")
+	}
+	if err := SetWindowIcon(image.NewRGBA(image.Rect(0, 0, 0, 0))); err == nil {
+		t.Errorf("SetWindowIcon with an empty image must return an error")
+	}
+	if err := SetWindowIcon(image.NewRGBA(image.Rect(0, 0, 16, 16))); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSetCursorMode(t *testing.T) {
+	defer SetCursorMode(CursorModeVisible)
+	if err := SetCursorMode(CursorModeHidden); err != nil {
+		t.Fatal(err)
+	}
+	for _, mode := range []CursorMode{-1, CursorModeCaptured + 1} {
+		if err := SetCursorMode(mode); err == nil {
+			t.Errorf("SetCursorMode(%d) must return an error", mode)
+		}
+	}
+	if got, want := CurrentCursorMode(), CursorModeHidden; got != want {
+		t.Errorf("CurrentCursorMode(): got %d; want %d", got, want)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hajimehoshi/ebiten/internal/graphics"
+	"github.com/hajimehoshi/ebiten/internal/opengl"
+	"github.com/hajimehoshi/ebiten/internal/ui"
+)
+
+// A Shader represents a user-defined fragment shader used by DrawImage.
+//
+// The source is a fragment shader in GLSL ES 1.0 (GLSL 1.10 on desktops) and can use these variables:
+//
+//     uniform sampler2D texture;               // The source image of DrawImage
+//     varying highp vec2 vertex_out_tex_coord; // The texture coordinate of the source image
+//
+// Precision qualifiers can be used as in GLSL ES: they are ignored on desktops, and highp is replaced with mediump
+// where highp is not available in fragment shaders.
+//
+// The colors of the images are alpha-premultiplied, and so should gl_FragColor be.
+// The uniform variables in DrawImageOptions.Uniforms are also available.
+// Images passed as uniform variables are sampled with the same texture coordinate,
+// so they should have the same size as the source image.
+//
+// Shaders are not available with the headless build tag.
+type Shader struct {
+	shader *graphics.Shader
+}
+
+// NewShader compiles the fragment shader source and returns a new shader.
+//
+// If the compilation fails, the error contains the log of the GLSL compiler with the line numbers.
+func NewShader(source string) (*Shader, error) {
+	var s *graphics.Shader
+	var err error
+	ui.Use(func(c *opengl.Context) {
+		s, err = graphics.NewShader(c, source)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Shader{s}, nil
+}
+
+// Dispose disposes the shader. After disposing, the shader can't be used.
+func (s *Shader) Dispose() (err error) {
+	if s.shader == nil {
+		return errors.New("ebiten: the shader is already disposed")
+	}
+	ui.Use(func(c *opengl.Context) {
+		err = s.shader.Dispose(c)
+	})
+	s.shader = nil
+	return
+}
+
+// uniforms converts the values of DrawImageOptions.Uniforms.
+func uniforms(target *Image, values map[string]interface{}) (map[string][]float32, map[string]*graphics.Texture, error) {
+	floats := map[string][]float32{}
+	textures := map[string]*graphics.Texture{}
+	for name, v := range values {
+		switch v := v.(type) {
+		case float32:
+			floats[name] = []float32{v}
+		case float64:
+			floats[name] = []float32{float32(v)}
+		case [2]float32:
+			floats[name] = v[:]
+		case [3]float32:
+			floats[name] = v[:]
+		case [4]float32:
+			floats[name] = v[:]
+		case [3][3]float32:
+			// v[i][j] is the element at the i-th row and the j-th column. GLSL matrices are column-major.
+			m := make([]float32, 9)
+			for i := 0; i < 3; i++ {
+				for j := 0; j < 3; j++ {
+					m[3*j+i] = v[i][j]
+				}
+			}
+			floats[name] = m
+		case [4][4]float32:
+			m := make([]float32, 16)
+			for i := 0; i < 4; i++ {
+				for j := 0; j < 4; j++ {
+					m[4*j+i] = v[i][j]
+				}
+			}
+			floats[name] = m
+		case *Image:
+			if v.isDisposed() {
+				return nil, nil, errImageDisposed
+			}
+			if v.parent != nil {
+				return nil, nil, errors.New(fmt.Sprintf("ebiten: uniform %s: a part of another image can't be a uniform", name))
+			}
+			if v.texture == target.texture {
+				return nil, nil, errors.New(fmt.Sprintf("ebiten: uniform %s: image should be different from the receiver", name))
+			}
+			textures[name] = v.texture
+		default:
+			return nil, nil, errors.New(fmt.Sprintf("ebiten: uniform %s: unsupported type %T", name, v))
+		}
+	}
+	return floats, textures, nil
+}
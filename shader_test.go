@@ -0,0 +1,124 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten_test
+
+import (
+	. "github.com/hajimehoshi/ebiten"
+	"image"
+	"testing"
+)
+
+func TestUniforms(t *testing.T) {
+	dst, err := NewImage(4, 4, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	src, err := NewImage(4, 4, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	floats, textures, err := Uniforms(dst, map[string]interface{}{
+		"f32":  float32(1),
+		"f64":  float64(2),
+		"vec2": [2]float32{1, 2},
+		"vec3": [3]float32{1, 2, 3},
+		"vec4": [4]float32{1, 2, 3, 4},
+		"mat3": [3][3]float32{
+			{1, 2, 3},
+			{4, 5, 6},
+			{7, 8, 9},
+		},
+		"mat4": [4][4]float32{
+			{1, 2, 3, 4},
+			{5, 6, 7, 8},
+			{9, 10, 11, 12},
+			{13, 14, 15, 16},
+		},
+		"image": src,
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	want := map[string][]float32{
+		"f32":  {1},
+		"f64":  {2},
+		"vec2": {1, 2},
+		"vec3": {1, 2, 3},
+		"vec4": {1, 2, 3, 4},
+		// The matrices are converted to column-major.
+		"mat3": {1, 4, 7, 2, 5, 8, 3, 6, 9},
+		"mat4": {1, 5, 9, 13, 2, 6, 10, 14, 3, 7, 11, 15, 4, 8, 12, 16},
+	}
+	if len(floats) != len(want) {
+		t.Errorf("len(floats): got %d, want: %d", len(floats), len(want))
+	}
+	for name, w := range want {
+		got := floats[name]
+		if len(got) != len(w) {
+			t.Errorf("floats[%q]: got %v, want: %v", name, got, w)
+			continue
+		}
+		for i := range w {
+			if got[i] != w[i] {
+				t.Errorf("floats[%q]: got %v, want: %v", name, got, w)
+				break
+			}
+		}
+	}
+	if len(textures) != 1 || textures["image"] == nil {
+		t.Errorf("textures: got %v, want only \"image\"", textures)
+	}
+}
+
+func TestUniformsError(t *testing.T) {
+	dst, err := NewImage(16, 16, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	disposed, err := NewImage(4, 4, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if err := disposed.Dispose(); err != nil {
+		t.Fatal(err)
+		return
+	}
+	other, err := NewImage(16, 16, FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	sub := other.SubImage(image.Rect(0, 0, 4, 4))
+	cases := []struct {
+		name  string
+		value interface{}
+	}{
+		{"unsupported type", 1},
+		{"unsupported array", [5]float32{}},
+		{"disposed image", disposed},
+		{"sub-image", sub},
+		{"receiver", dst},
+	}
+	for _, c := range cases {
+		if _, _, err := Uniforms(dst, map[string]interface{}{"u": c.value}); err == nil {
+			t.Errorf("%s: an error is expected", c.name)
+		}
+	}
+}
@@ -94,3 +94,55 @@ func (r *rect) Rect(i int) (x, y, width, height int) {
 func (r *rect) Color(i int) color.Color {
 	return r.color
 }
+
+// A FloatLines represents the set of lines in floating-point coordinates.
+type FloatLines interface {
+	Len() int
+	Points(i int) (x0, y0, x1, y1 float64)
+	Color(i int) color.Color
+}
+
+// A FloatRects represents the set of rectangles in floating-point coordinates.
+type FloatRects interface {
+	Len() int
+	Rect(i int) (x, y, width, height float64)
+	Color(i int) color.Color
+}
+
+// The types below adapt the public interfaces to the float32 ones of the internal graphics package.
+
+type intLines struct {
+	Lines
+}
+
+func (l *intLines) Points(i int) (x0, y0, x1, y1 float32) {
+	ix0, iy0, ix1, iy1 := l.Lines.Points(i)
+	return float32(ix0), float32(iy0), float32(ix1), float32(iy1)
+}
+
+type floatLines struct {
+	FloatLines
+}
+
+func (l *floatLines) Points(i int) (x0, y0, x1, y1 float32) {
+	fx0, fy0, fx1, fy1 := l.FloatLines.Points(i)
+	return float32(fx0), float32(fy0), float32(fx1), float32(fy1)
+}
+
+type intRects struct {
+	Rects
+}
+
+func (r *intRects) Rect(i int) (x, y, width, height float32) {
+	ix, iy, iw, ih := r.Rects.Rect(i)
+	return float32(ix), float32(iy), float32(iw), float32(ih)
+}
+
+type floatRects struct {
+	FloatRects
+}
+
+func (r *floatRects) Rect(i int) (x, y, width, height float32) {
+	fx, fy, fw, fh := r.FloatRects.Rect(i)
+	return float32(fx), float32(fy), float32(fw), float32(fh)
+}
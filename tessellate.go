@@ -0,0 +1,349 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+import (
+	"math"
+	"sort"
+)
+
+type point struct {
+	x, y float64
+}
+
+func (p point) add(q point) point {
+	return point{p.x + q.x, p.y + q.y}
+}
+
+func (p point) sub(q point) point {
+	return point{p.x - q.x, p.y - q.y}
+}
+
+func (p point) mul(s float64) point {
+	return point{p.x * s, p.y * s}
+}
+
+func (p point) dot(q point) float64 {
+	return p.x*q.x + p.y*q.y
+}
+
+func (p point) cross(q point) float64 {
+	return p.x*q.y - p.y*q.x
+}
+
+func (p point) len() float64 {
+	return math.Hypot(p.x, p.y)
+}
+
+// A polyline is a flattened subpath.
+type polyline struct {
+	points []point
+	closed bool
+}
+
+func signedArea(polygon []point) float64 {
+	a := 0.0
+	for i, p := range polygon {
+		q := polygon[(i+1)%len(polygon)]
+		a += p.cross(q)
+	}
+	return a / 2
+}
+
+type edge struct {
+	x0, y0, x1, y1 float64
+	winding        int
+}
+
+func (e *edge) x(y float64) float64 {
+	return e.x0 + (e.x1-e.x0)*(y-e.y0)/(e.y1-e.y0)
+}
+
+type edgesByY []*edge
+
+func (e edgesByY) Len() int {
+	return len(e)
+}
+
+func (e edgesByY) Less(i, j int) bool {
+	return e[i].y0 < e[j].y0
+}
+
+func (e edgesByY) Swap(i, j int) {
+	e[i], e[j] = e[j], e[i]
+}
+
+// A crossing is an edge in a band: xa, xb and xm are the x at the top, the bottom and the middle of the band.
+type crossing struct {
+	edge       *edge
+	xa, xb, xm float64
+}
+
+type crossingsByX []crossing
+
+func (c crossingsByX) Len() int {
+	return len(c)
+}
+
+func (c crossingsByX) Less(i, j int) bool {
+	return c[i].xm < c[j].xm
+}
+
+func (c crossingsByX) Swap(i, j int) {
+	c[i], c[j] = c[j], c[i]
+}
+
+// fillTriangles tessellates the polygons into triangles and returns their vertices.
+//
+// The polygons are split into horizontal bands at every vertex and every intersection of edges.
+// In each band no edges cross, so the inside parts are trapezoids determined by the fill rule.
+// This works for concave and self-intersecting polygons and polygons with holes.
+func fillTriangles(polygons [][]point, evenOdd bool) []point {
+	edges := []*edge{}
+	for _, polygon := range polygons {
+		for i, p := range polygon {
+			q := polygon[(i+1)%len(polygon)]
+			switch {
+			case p.y < q.y:
+				edges = append(edges, &edge{p.x, p.y, q.x, q.y, 1})
+			case q.y < p.y:
+				edges = append(edges, &edge{q.x, q.y, p.x, p.y, -1})
+			}
+		}
+	}
+	if len(edges) == 0 {
+		return nil
+	}
+	sort.Sort(edgesByY(edges))
+
+	ys := make([]float64, 0, 2*len(edges))
+	for i, e0 := range edges {
+		ys = append(ys, e0.y0, e0.y1)
+		for _, e1 := range edges[i+1:] {
+			if e0.y1 <= e1.y0 {
+				break
+			}
+			if y, ok := intersectionY(e0, e1); ok {
+				ys = append(ys, y)
+			}
+		}
+	}
+	sort.Float64s(ys)
+
+	vertices := []point{}
+	active := []*edge{}
+	next := 0
+	for k := 0; k+1 < len(ys); k++ {
+		ya, yb := ys[k], ys[k+1]
+		if ya == yb {
+			continue
+		}
+		for next < len(edges) && edges[next].y0 <= ya {
+			active = append(active, edges[next])
+			next++
+		}
+		crossings := []crossing{}
+		n := 0
+		for _, e := range active {
+			if e.y1 <= ya {
+				continue
+			}
+			active[n] = e
+			n++
+			crossings = append(crossings, crossing{e, e.x(ya), e.x(yb), e.x((ya + yb) / 2)})
+		}
+		active = active[:n]
+		sort.Sort(crossingsByX(crossings))
+		winding := 0
+		var left crossing
+		for _, c := range crossings {
+			inside := winding != 0
+			if evenOdd {
+				inside = winding%2 != 0
+			}
+			winding += c.edge.winding
+			nextInside := winding != 0
+			if evenOdd {
+				nextInside = winding%2 != 0
+			}
+			switch {
+			case !inside && nextInside:
+				left = c
+			case inside && !nextInside:
+				p0, p1 := point{left.xa, ya}, point{c.xa, ya}
+				p2, p3 := point{left.xb, yb}, point{c.xb, yb}
+				vertices = append(vertices, p0, p1, p2, p1, p3, p2)
+			}
+		}
+	}
+	return vertices
+}
+
+// intersectionY returns the y of the intersection of the edges if they cross strictly inside both edges.
+func intersectionY(e0, e1 *edge) (float64, bool) {
+	d0 := point{e0.x1 - e0.x0, e0.y1 - e0.y0}
+	d1 := point{e1.x1 - e1.x0, e1.y1 - e1.y0}
+	denom := d0.cross(d1)
+	if denom == 0 {
+		return 0, false
+	}
+	r := point{e1.x0 - e0.x0, e1.y0 - e0.y0}
+	t := r.cross(d1) / denom
+	u := r.cross(d0) / denom
+	if t <= 0 || 1 <= t || u <= 0 || 1 <= u {
+		return 0, false
+	}
+	return e0.y0 + t*d0.y, true
+}
+
+// arcSegmentNum returns the number of segments to approximate an arc with the radius r and the angle
+// within the tolerance.
+func arcSegmentNum(r, angle, tolerance float64) int {
+	if r <= tolerance {
+		return int(math.Ceil(math.Abs(angle) / (math.Pi / 2)))
+	}
+	step := 2 * math.Acos(1-tolerance/r)
+	return int(math.Max(math.Ceil(math.Abs(angle)/step), 1))
+}
+
+// arc returns the points of the arc from the angle a0 to a0+sweep with the center c and the radius r.
+func arc(c point, r, a0, sweep, tolerance float64) []point {
+	n := arcSegmentNum(r, sweep, tolerance)
+	points := make([]point, 0, n+1)
+	for i := 0; i <= n; i++ {
+		s, c0 := math.Sincos(a0 + sweep*float64(i)/float64(n))
+		points = append(points, c.add(point{c0 * r, s * r}))
+	}
+	return points
+}
+
+func angle(p point) float64 {
+	return math.Atan2(p.y, p.x)
+}
+
+// strokePolygons returns polygons whose union is the stroke of the polyline.
+// All the polygons are counterclockwise so that they can be filled by the non-zero rule without overlaps.
+func strokePolygons(l *polyline, s *StrokeOptions, tolerance float64) [][]point {
+	points := make([]point, 0, len(l.points))
+	for _, p := range l.points {
+		if len(points) > 0 && points[len(points)-1] == p {
+			continue
+		}
+		points = append(points, p)
+	}
+	closed := l.closed
+	if closed && len(points) > 1 && points[0] == points[len(points)-1] {
+		points = points[:len(points)-1]
+	}
+	if len(points) < 2 {
+		return nil
+	}
+	if len(points) == 2 {
+		closed = false
+	}
+
+	hw := s.Width / 2
+	segNum := len(points) - 1
+	if closed {
+		segNum = len(points)
+	}
+	dirs := make([]point, segNum)
+	for i := range dirs {
+		d := points[(i+1)%len(points)].sub(points[i])
+		dirs[i] = d.mul(1 / d.len())
+	}
+	normal := func(d point) point {
+		return point{-d.y, d.x}
+	}
+
+	polygons := [][]point{}
+	for i, d := range dirs {
+		a, b := points[i], points[(i+1)%len(points)]
+		n := normal(d).mul(hw)
+		polygons = append(polygons, []point{a.add(n), b.add(n), b.sub(n), a.sub(n)})
+	}
+
+	// Joins
+	for i := range points {
+		if !closed && (i == 0 || i == len(points)-1) {
+			continue
+		}
+		d0, d1 := dirs[(i+segNum-1)%segNum], dirs[i%segNum]
+		cross := d0.cross(d1)
+		if cross == 0 && 0 < d0.dot(d1) {
+			continue
+		}
+		p := points[i]
+		// The outer side of the turn.
+		sign := 1.0
+		if 0 < cross {
+			sign = -1
+		}
+		n0, n1 := normal(d0).mul(sign), normal(d1).mul(sign)
+		switch s.LineJoin {
+		case LineJoinRound:
+			a0, a1 := angle(n0), angle(n1)
+			sweep := math.Remainder(a1-a0, 2*math.Pi)
+			if cross == 0 {
+				sweep = math.Pi
+			}
+			polygons = append(polygons, append([]point{p}, arc(p, hw, a0, sweep, tolerance)...))
+		case LineJoinMiter:
+			m := n0.add(n1)
+			if ml := m.len(); ml != 0 && 2/ml <= s.MiterLimit {
+				polygons = append(polygons, []point{p, p.add(n0.mul(hw)), p.add(m.mul(hw / (1 + n0.dot(n1)))), p.add(n1.mul(hw))})
+				continue
+			}
+			fallthrough
+		default:
+			polygons = append(polygons, []point{p, p.add(n0.mul(hw)), p.add(n1.mul(hw))})
+		}
+	}
+
+	// Caps
+	if !closed {
+		caps := []struct {
+			p, d point
+		}{
+			{points[0], dirs[0].mul(-1)},
+			{points[len(points)-1], dirs[segNum-1]},
+		}
+		for _, c := range caps {
+			n := normal(c.d).mul(hw)
+			switch s.LineCap {
+			case LineCapRound:
+				polygons = append(polygons, arc(c.p, hw, angle(n), -math.Pi, tolerance))
+			case LineCapSquare:
+				e := c.d.mul(hw)
+				polygons = append(polygons, []point{c.p.add(n), c.p.add(n).add(e), c.p.sub(n).add(e), c.p.sub(n)})
+			}
+		}
+	}
+
+	result := polygons[:0]
+	for _, polygon := range polygons {
+		a := signedArea(polygon)
+		if a == 0 {
+			continue
+		}
+		if a < 0 {
+			for i, j := 0, len(polygon)-1; i < j; i, j = i+1, j-1 {
+				polygon[i], polygon[j] = polygon[j], polygon[i]
+			}
+		}
+		result = append(result, polygon)
+	}
+	return result
+}
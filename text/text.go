@@ -0,0 +1,363 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package text provides functions to draw texts with TrueType/OpenType fonts.
+//
+// A font.Face can be created e.g. by github.com/golang/freetype/truetype.NewFace.
+// Glyphs are rasterized only once per face and cached in shared atlas images.
+package text
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hajimehoshi/ebiten"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+)
+
+// atlasSize is the width and the height of an atlas page.
+const atlasSize = 1024
+
+type glyph struct {
+	page    *atlasPage
+	src     image.Rectangle
+	bounds  image.Rectangle
+	advance fixed.Int26_6
+}
+
+// An atlasPage is an image where glyphs are packed into rows (shelves).
+type atlasPage struct {
+	image     *ebiten.Image
+	x         int
+	y         int
+	rowHeight int
+}
+
+func (p *atlasPage) alloc(width, height int) (image.Point, bool) {
+	// Leave 1 pixel between glyphs not to bleed.
+	width++
+	height++
+	// The page is updated only when the region is allocated,
+	// or a failed allocation would abandon the current row.
+	x, y, rowHeight := p.x, p.y, p.rowHeight
+	if atlasSize < x+width {
+		x = 0
+		y += rowHeight
+		rowHeight = 0
+	}
+	if atlasSize < x+width || atlasSize < y+height {
+		return image.Point{}, false
+	}
+	if rowHeight < height {
+		rowHeight = height
+	}
+	p.x, p.y, p.rowHeight = x+width, y, rowHeight
+	return image.Point{x, y}, true
+}
+
+type atlas struct {
+	pages  []*atlasPage
+	glyphs map[rune]*glyph
+}
+
+var (
+	atlases = map[font.Face]*atlas{}
+	m       sync.Mutex
+)
+
+// checkGlyphSize returns an error when a glyph of the size can't be put into an atlas page.
+func checkGlyphSize(width, height int) error {
+	// A glyph needs 1 more pixel for the margin.
+	if atlasSize < width+1 || atlasSize < height+1 {
+		return errors.New(fmt.Sprintf("text: glyph is too large: %dx%d", width, height))
+	}
+	return nil
+}
+
+func (a *atlas) alloc(width, height int) (*atlasPage, image.Point, error) {
+	if err := checkGlyphSize(width, height); err != nil {
+		return nil, image.Point{}, err
+	}
+	if 0 < len(a.pages) {
+		p := a.pages[len(a.pages)-1]
+		if pt, ok := p.alloc(width, height); ok {
+			return p, pt, nil
+		}
+	}
+	img, err := ebiten.NewImage(atlasSize, atlasSize, ebiten.FilterNearest)
+	if err != nil {
+		return nil, image.Point{}, err
+	}
+	p := &atlasPage{image: img}
+	a.pages = append(a.pages, p)
+	pt, ok := p.alloc(width, height)
+	if !ok {
+		panic("not reach")
+	}
+	return p, pt, nil
+}
+
+// reserve allocates the atlas space for the glyphs.
+// If the allocation fails, the atlas is restored so that no space is wasted.
+func (a *atlas) reserve(glyphs []*glyph) error {
+	pageNum := len(a.pages)
+	var last atlasPage
+	if 0 < pageNum {
+		last = *a.pages[pageNum-1]
+	}
+	for _, g := range glyphs {
+		w, h := g.bounds.Dx(), g.bounds.Dy()
+		page, pt, err := a.alloc(w, h)
+		if err != nil {
+			for _, p := range a.pages[pageNum:] {
+				p.image.Dispose()
+			}
+			a.pages = a.pages[:pageNum]
+			if 0 < pageNum {
+				*a.pages[pageNum-1] = last
+			}
+			return err
+		}
+		g.page = page
+		g.src = image.Rectangle{pt, pt.Add(image.Pt(w, h))}
+	}
+	return nil
+}
+
+type part struct {
+	dst image.Rectangle
+	src image.Rectangle
+}
+
+type parts []part
+
+func (p parts) Len() int {
+	return len(p)
+}
+
+func (p parts) Dst(i int) (x0, y0, x1, y1 int) {
+	d := &p[i].dst
+	return d.Min.X, d.Min.Y, d.Max.X, d.Max.Y
+}
+
+func (p parts) Src(i int) (x0, y0, x1, y1 int) {
+	s := &p[i].src
+	return s.Min.X, s.Min.Y, s.Max.X, s.Max.Y
+}
+
+type newGlyph struct {
+	rune  rune
+	glyph *glyph
+	mask  image.Image
+	maskp image.Point
+}
+
+// cacheGlyphs rasterizes the glyphs of text which are not cached yet and puts them into the atlas.
+//
+// The new glyphs are rendered into strips no wider than an atlas page, and each strip is uploaded at once.
+// The glyphs in a strip are cached when the strip is put into the atlas successfully.
+func (a *atlas) cacheGlyphs(face font.Face, text string) error {
+	strips := [][]newGlyph{}
+	strip := []newGlyph{}
+	stripWidth := 0
+	empty := map[rune]*glyph{}
+	added := map[rune]bool{}
+	for _, r := range text {
+		if _, ok := a.glyphs[r]; ok {
+			continue
+		}
+		if added[r] {
+			continue
+		}
+		added[r] = true
+		dr, mask, maskp, advance, ok := face.Glyph(fixed.Point26_6{}, r)
+		g := &glyph{bounds: dr, advance: advance}
+		if !ok || dr.Empty() {
+			empty[r] = g
+			continue
+		}
+		if err := checkGlyphSize(dr.Dx(), dr.Dy()); err != nil {
+			return err
+		}
+		if atlasSize < stripWidth+dr.Dx() {
+			strips = append(strips, strip)
+			strip = []newGlyph{}
+			stripWidth = 0
+		}
+		strip = append(strip, newGlyph{r, g, mask, maskp})
+		stripWidth += dr.Dx()
+	}
+	if 0 < len(strip) {
+		strips = append(strips, strip)
+	}
+	// Glyphs without images don't use the atlas.
+	for r, g := range empty {
+		a.glyphs[r] = g
+	}
+	for _, strip := range strips {
+		if err := a.cacheStrip(strip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cacheStrip renders the glyphs into one strip image, uploads it and copies it to the atlas pages.
+// The atlas space is reserved only after the strip is uploaded.
+func (a *atlas) cacheStrip(glyphs []newGlyph) error {
+	width, height := 0, 0
+	for _, n := range glyphs {
+		width += n.glyph.bounds.Dx()
+		if height < n.glyph.bounds.Dy() {
+			height = n.glyph.bounds.Dy()
+		}
+	}
+	strip := image.NewRGBA(image.Rect(0, 0, width, height))
+	srcs := make([]image.Rectangle, len(glyphs))
+	gs := make([]*glyph, len(glyphs))
+	x := 0
+	for i, n := range glyphs {
+		w, h := n.glyph.bounds.Dx(), n.glyph.bounds.Dy()
+		srcs[i] = image.Rect(x, 0, x+w, h)
+		gs[i] = n.glyph
+		draw.DrawMask(strip, srcs[i], image.White, image.ZP, n.mask, n.maskp, draw.Over)
+		x += w
+	}
+	stripImage, err := ebiten.NewImageFromImage(strip, ebiten.FilterNearest)
+	if err != nil {
+		return err
+	}
+	if err := a.copyStrip(stripImage, gs, srcs); err != nil {
+		stripImage.Dispose()
+		return err
+	}
+	for _, n := range glyphs {
+		a.glyphs[n.rune] = n.glyph
+	}
+	return stripImage.Dispose()
+}
+
+// copyStrip reserves the atlas space for the glyphs and copies the parts srcs of the strip image there.
+func (a *atlas) copyStrip(strip *ebiten.Image, glyphs []*glyph, srcs []image.Rectangle) error {
+	if err := a.reserve(glyphs); err != nil {
+		return err
+	}
+	partsByPage := map[*atlasPage]parts{}
+	for i, g := range glyphs {
+		partsByPage[g.page] = append(partsByPage[g.page], part{dst: g.src, src: srcs[i]})
+	}
+	for page, ps := range partsByPage {
+		if err := page.image.DrawImage(strip, &ebiten.DrawImageOptions{ImageParts: ps}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func atlasFor(face font.Face) *atlas {
+	a, ok := atlases[face]
+	if !ok {
+		a = &atlas{glyphs: map[rune]*glyph{}}
+		atlases[face] = a
+	}
+	return a
+}
+
+// Draw draws the text on dst with the face and the color.
+//
+// (x, y) is the origin of the first line, which is on the baseline.
+// The text can include line breaks ('\n').
+func Draw(dst *ebiten.Image, text string, face font.Face, x, y int, clr color.Color) error {
+	m.Lock()
+	defer m.Unlock()
+
+	a := atlasFor(face)
+	if err := a.cacheGlyphs(face, text); err != nil {
+		return err
+	}
+
+	partsByPage := map[*atlasPage]parts{}
+	pages := []*atlasPage{}
+	dot := fixed.P(x, y)
+	prev := rune(-1)
+	for _, r := range text {
+		if r == '\n' {
+			dot.X = fixed.I(x)
+			dot.Y += face.Metrics().Height
+			prev = -1
+			continue
+		}
+		if 0 <= prev {
+			dot.X += face.Kern(prev, r)
+		}
+		g := a.glyphs[r]
+		if g.page != nil {
+			d := g.bounds.Add(image.Pt(dot.X.Round(), dot.Y.Round()))
+			if _, ok := partsByPage[g.page]; !ok {
+				pages = append(pages, g.page)
+			}
+			partsByPage[g.page] = append(partsByPage[g.page], part{dst: d, src: g.src})
+		}
+		dot.X += g.advance
+		prev = r
+	}
+
+	// Glyphs in the atlas are white. Scale them by the color.
+	cr, cg, cb, ca := clr.RGBA()
+	if ca == 0 {
+		return nil
+	}
+	op := &ebiten.DrawImageOptions{}
+	op.ColorM.Scale(float64(cr)/float64(ca), float64(cg)/float64(ca), float64(cb)/float64(ca), float64(ca)/0xffff)
+	for _, p := range pages {
+		op.ImageParts = partsByPage[p]
+		if err := dst.DrawImage(p.image, op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MeasureString returns the size of the area where the text is drawn.
+//
+// The width is the advance of the longest line and the height is the line height multiplied by the number of lines.
+func MeasureString(text string, face font.Face) (width, height int) {
+	lineHeight := face.Metrics().Height
+	w := fixed.Int26_6(0)
+	h := lineHeight
+	x := fixed.Int26_6(0)
+	prev := rune(-1)
+	for _, r := range text {
+		if r == '\n' {
+			x = 0
+			h += lineHeight
+			prev = -1
+			continue
+		}
+		if 0 <= prev {
+			x += face.Kern(prev, r)
+		}
+		a, _ := face.GlyphAdvance(r)
+		x += a
+		if w < x {
+			w = x
+		}
+		prev = r
+	}
+	return w.Ceil(), h.Ceil()
+}
@@ -0,0 +1,158 @@
+// Copyright 2015 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text_test
+
+import (
+	"github.com/hajimehoshi/ebiten"
+	. "github.com/hajimehoshi/ebiten/text"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestMeasureString(t *testing.T) {
+	// Each glyph of Face7x13 has the advance 7 and the line height is 13.
+	testCases := []struct {
+		text   string
+		width  int
+		height int
+	}{
+		{"", 0, 13},
+		{"abc", 21, 13},
+		{"ab\nabcd", 28, 26},
+		{"abcd\n", 28, 26},
+	}
+	for _, c := range testCases {
+		w, h := MeasureString(c.text, basicfont.Face7x13)
+		if w != c.width || h != c.height {
+			t.Errorf("MeasureString(%q): got (%d, %d); want (%d, %d)", c.text, w, h, c.width, c.height)
+		}
+	}
+}
+
+func TestDraw(t *testing.T) {
+	const (
+		w    = 32
+		h    = 32
+		text = "Ab\nc"
+	)
+	dst, err := ebiten.NewImage(w, h, ebiten.FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clr := color.RGBA{0xff, 0, 0, 0xff}
+	if err := Draw(dst, text, basicfont.Face7x13, 1, 11, clr); err != nil {
+		t.Fatal(err)
+	}
+
+	// Render the expected image with the face directly.
+	want := image.NewRGBA(image.Rect(0, 0, w, h))
+	face := basicfont.Face7x13
+	dot := fixed.P(1, 11)
+	for _, r := range text {
+		if r == '\n' {
+			dot.X = fixed.I(1)
+			dot.Y += face.Metrics().Height
+			continue
+		}
+		dr, mask, maskp, advance, _ := face.Glyph(dot, r)
+		draw.DrawMask(want, dr, image.NewUniform(clr), image.ZP, mask, maskp, draw.Over)
+		dot.X += advance
+	}
+
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			got := dst.At(i, j)
+			want := want.At(i, j)
+			if got != want {
+				t.Errorf("dst.At(%d, %d): got %#v; want %#v", i, j, got, want)
+			}
+		}
+	}
+}
+
+// largeFace is a face whose glyphs are larger than an atlas page.
+type largeFace struct {
+	font.Face
+}
+
+func (f *largeFace) Glyph(dot fixed.Point26_6, r rune) (dr image.Rectangle, mask image.Image, maskp image.Point, advance fixed.Int26_6, ok bool) {
+	const size = 2048
+	dr = image.Rect(0, -size, size, 0).Add(image.Pt(dot.X.Round(), dot.Y.Round()))
+	return dr, image.Opaque, image.ZP, fixed.I(size), true
+}
+
+func TestDrawTooLargeGlyph(t *testing.T) {
+	dst, err := ebiten.NewImage(16, 16, ebiten.FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	face := &largeFace{basicfont.Face7x13}
+	for i := 0; i < 2; i++ {
+		// The failed glyph must not be cached.
+		if err := Draw(dst, "a", face, 0, 0, color.White); err == nil {
+			t.Errorf("Draw with a too large glyph must return an error")
+		}
+	}
+}
+
+// wideFace is a face whose glyphs are 200 pixels wide and filled with an alpha value depending on the rune.
+type wideFace struct {
+	font.Face
+}
+
+func wideGlyphAlpha(r rune) uint8 {
+	return uint8(r-'a'+1) * 16
+}
+
+func (f *wideFace) Glyph(dot fixed.Point26_6, r rune) (dr image.Rectangle, mask image.Image, maskp image.Point, advance fixed.Int26_6, ok bool) {
+	const width, height = 200, 4
+	dr = image.Rect(0, -height, width, 0).Add(image.Pt(dot.X.Round(), dot.Y.Round()))
+	return dr, image.NewUniform(color.Alpha{wideGlyphAlpha(r)}), image.ZP, fixed.I(width), true
+}
+
+func TestDrawManyWideGlyphs(t *testing.T) {
+	// The new glyphs are wider than an atlas page in total and are put into the atlas in several strips.
+	const text = "abcdefghijkl"
+	face := &wideFace{basicfont.Face7x13}
+	dst, err := ebiten.NewImage(16, 4, ebiten.FilterNearest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Draw(dst, text, face, 0, 4, color.White); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range text {
+		if err := dst.Clear(); err != nil {
+			t.Fatal(err)
+		}
+		if err := Draw(dst, string(r), face, 0, 4, color.White); err != nil {
+			t.Fatal(err)
+		}
+		a := wideGlyphAlpha(r)
+		want := color.RGBA{a, a, a, a}
+		for j := 0; j < 4; j++ {
+			for i := 0; i < 16; i++ {
+				if got := dst.At(i, j); got != want {
+					t.Errorf("%q: dst.At(%d, %d): got %#v; want %#v", r, i, j, got, want)
+				}
+			}
+		}
+	}
+}